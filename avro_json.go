@@ -0,0 +1,432 @@
+package avroturf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hamba/avro"
+)
+
+func UnmarshalAvroJSON(schema *Schema, data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var j interface{}
+	if err := decoder.Decode(&j); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return avroJSONDatum(schema.Schema, j)
+}
+
+func avroJSONDatum(schema avro.Schema, j interface{}) (interface{}, error) {
+	switch s := derefSchema(schema).(type) {
+	case *avro.NullSchema:
+		if j != nil {
+			return nil, fmt.Errorf("expected null but got %v", j)
+		}
+		return nil, nil
+	case *avro.PrimitiveSchema:
+		return avroJSONPrimitive(s, j)
+	case *avro.FixedSchema:
+		if n, ok := j.(json.Number); ok && s.Logical() != nil && s.Logical().Type() == avro.Decimal {
+			return decimalBytes(n, s.Logical().(*avro.DecimalLogicalSchema), s.Size())
+		}
+		str, ok := j.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string for fixed %s but got %v", s.FullName(), j)
+		}
+		b, err := latin1Bytes(str)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != s.Size() {
+			return nil, fmt.Errorf("expected %d bytes for fixed %s but got %d", s.Size(), s.FullName(), len(b))
+		}
+		return b, nil
+	case *avro.EnumSchema:
+		str, ok := j.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string for enum %s but got %v", s.FullName(), j)
+		}
+		for _, symbol := range s.Symbols() {
+			if symbol == str {
+				return str, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown symbol %q for enum %s", str, s.FullName())
+	case *avro.RecordSchema:
+		obj, ok := j.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object for record %s but got %v", s.FullName(), j)
+		}
+		record := make(map[string]interface{}, len(s.Fields()))
+		for _, field := range s.Fields() {
+			val, hit := obj[field.Name()]
+			if !hit {
+				if !field.HasDefault() {
+					return nil, fmt.Errorf("missing field %s.%s", s.FullName(), field.Name())
+				}
+				def, err := defaultDatum(field.Type(), field.Default())
+				if err != nil {
+					return nil, err
+				}
+				record[field.Name()] = def
+				continue
+			}
+			v, err := avroJSONDatum(field.Type(), val)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %v", s.FullName(), field.Name(), err)
+			}
+			record[field.Name()] = v
+		}
+		return record, nil
+	case *avro.ArraySchema:
+		arr, ok := j.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected array but got %v", j)
+		}
+		items := make([]interface{}, len(arr))
+		for i, item := range arr {
+			v, err := avroJSONDatum(s.Items(), item)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %v", i, err)
+			}
+			items[i] = v
+		}
+		return items, nil
+	case *avro.MapSchema:
+		obj, ok := j.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected object for map but got %v", j)
+		}
+		values := make(map[string]interface{}, len(obj))
+		for key, val := range obj {
+			v, err := avroJSONDatum(s.Values(), val)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			values[key] = v
+		}
+		return values, nil
+	case *avro.UnionSchema:
+		if j == nil {
+			if branch, _ := findUnionBranch(s, string(avro.Null)); branch == nil {
+				return nil, fmt.Errorf("union %s does not accept null", s.String())
+			}
+			return nil, nil
+		}
+		name, val, err := unionBranch(j)
+		if err != nil {
+			return nil, err
+		}
+		branch, _ := findUnionBranch(s, name)
+		if branch == nil {
+			return nil, fmt.Errorf("unknown union branch: %s", name)
+		}
+		v, err := avroJSONDatum(branch, val)
+		if err != nil {
+			return nil, err
+		}
+		if branch.Type() == avro.Null {
+			return nil, nil
+		}
+		return map[string]interface{}{name: v}, nil
+	}
+	return nil, fmt.Errorf("unexpected schema type: %s", schema.Type())
+}
+
+func avroJSONPrimitive(s *avro.PrimitiveSchema, j interface{}) (interface{}, error) {
+	var logical avro.LogicalType
+	if s.Logical() != nil {
+		logical = s.Logical().Type()
+	}
+	switch s.Type() {
+	case avro.Boolean:
+		b, ok := j.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected boolean but got %v", j)
+		}
+		return b, nil
+	case avro.Int:
+		if str, ok := j.(string); ok && logical == avro.Date {
+			t, err := time.Parse("2006-01-02", str)
+			if err != nil {
+				return nil, err
+			}
+			return int32(t.Unix() / 86400), nil
+		}
+		n, ok := j.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("expected int but got %v", j)
+		}
+		i, err := strconv.ParseInt(n.String(), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		return int32(i), nil
+	case avro.Long:
+		if str, ok := j.(string); ok && (logical == avro.TimestampMillis || logical == avro.TimestampMicros) {
+			t, err := time.Parse(time.RFC3339Nano, str)
+			if err != nil {
+				return nil, err
+			}
+			if logical == avro.TimestampMillis {
+				return t.UnixNano() / int64(time.Millisecond), nil
+			}
+			return t.UnixNano() / int64(time.Microsecond), nil
+		}
+		n, ok := j.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("expected long but got %v", j)
+		}
+		return n.Int64()
+	case avro.Float, avro.Double:
+		var f float64
+		switch n := j.(type) {
+		case json.Number:
+			v, err := n.Float64()
+			if err != nil {
+				return nil, err
+			}
+			f = v
+		case string:
+			switch n {
+			case "NaN":
+				f = math.NaN()
+			case "Infinity":
+				f = math.Inf(1)
+			case "-Infinity":
+				f = math.Inf(-1)
+			default:
+				return nil, fmt.Errorf("expected %s but got %q", s.Type(), n)
+			}
+		default:
+			return nil, fmt.Errorf("expected %s but got %v", s.Type(), j)
+		}
+		if s.Type() == avro.Float {
+			return float32(f), nil
+		}
+		return f, nil
+	case avro.Bytes:
+		if n, ok := j.(json.Number); ok && logical == avro.Decimal {
+			return decimalBytes(n, s.Logical().(*avro.DecimalLogicalSchema), 0)
+		}
+		str, ok := j.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string for bytes but got %v", j)
+		}
+		return latin1Bytes(str)
+	case avro.String:
+		str, ok := j.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string but got %v", j)
+		}
+		return str, nil
+	}
+	return nil, fmt.Errorf("unexpected primitive type: %s", s.Type())
+}
+
+func decimalBytes(n json.Number, logical *avro.DecimalLogicalSchema, size int) ([]byte, error) {
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal: %s", n)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(logical.Scale())), nil)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("decimal %s exceeds scale %d", n, logical.Scale())
+	}
+	unscaled := r.Num()
+	length := size
+	if length == 0 {
+		length = unscaled.BitLen()/8 + 1
+	}
+	b := make([]byte, length)
+	twos := new(big.Int).Set(unscaled)
+	if unscaled.Sign() < 0 {
+		twos.Add(twos, new(big.Int).Lsh(big.NewInt(1), uint(length*8)))
+	}
+	raw := twos.Bytes()
+	if len(raw) > length || (unscaled.Sign() >= 0 && unscaled.BitLen() >= length*8) || (unscaled.Sign() < 0 && twos.BitLen() < length*8) {
+		return nil, fmt.Errorf("decimal %s does not fit in %d bytes", n, length)
+	}
+	copy(b[length-len(raw):], raw)
+	if unscaled.Sign() < 0 {
+		for i := 0; i < length-len(raw); i++ {
+			b[i] = 0xff
+		}
+	}
+	return b, nil
+}
+
+func MarshalAvroJSON(schema *Schema, v interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := writeAvroJSON(buf, schema.Schema, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeAvroJSON(buf *bytes.Buffer, schema avro.Schema, v interface{}) error {
+	switch s := derefSchema(schema).(type) {
+	case *avro.NullSchema:
+		buf.WriteString("null")
+	case *avro.PrimitiveSchema:
+		switch s.Type() {
+		case avro.Boolean:
+			b, ok := v.(bool)
+			if !ok {
+				return fmt.Errorf("expected bool but got %T", v)
+			}
+			buf.WriteString(strconv.FormatBool(b))
+		case avro.Int, avro.Long:
+			i, ok := toInt64(v)
+			if !ok {
+				return fmt.Errorf("expected %s but got %T", s.Type(), v)
+			}
+			buf.WriteString(strconv.FormatInt(i, 10))
+		case avro.Float, avro.Double:
+			f, ok := toFloat64(v)
+			if !ok {
+				return fmt.Errorf("expected %s but got %T", s.Type(), v)
+			}
+			writeJSONFloat(buf, f, s.Type())
+		case avro.Bytes:
+			b, ok := v.([]byte)
+			if !ok {
+				return fmt.Errorf("expected bytes but got %T", v)
+			}
+			writeJSONString(buf, latin1String(b))
+		case avro.String:
+			str, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("expected string but got %T", v)
+			}
+			writeJSONString(buf, str)
+		}
+	case *avro.FixedSchema:
+		b, ok := v.([]byte)
+		if !ok {
+			return fmt.Errorf("expected bytes for fixed %s but got %T", s.FullName(), v)
+		}
+		writeJSONString(buf, latin1String(b))
+	case *avro.EnumSchema:
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("expected string for enum %s but got %T", s.FullName(), v)
+		}
+		writeJSONString(buf, str)
+	case *avro.RecordSchema:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected map for record %s but got %T", s.FullName(), v)
+		}
+		buf.WriteByte('{')
+		for i, field := range s.Fields() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, field.Name())
+			buf.WriteByte(':')
+			if err := writeAvroJSON(buf, field.Type(), obj[field.Name()]); err != nil {
+				return fmt.Errorf("%s.%s: %v", s.FullName(), field.Name(), err)
+			}
+		}
+		buf.WriteByte('}')
+	case *avro.ArraySchema:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected slice for array but got %T", v)
+		}
+		buf.WriteByte('[')
+		for i, item := range arr {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeAvroJSON(buf, s.Items(), item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case *avro.MapSchema:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected map for map but got %T", v)
+		}
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, key)
+			buf.WriteByte(':')
+			if err := writeAvroJSON(buf, s.Values(), obj[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case *avro.UnionSchema:
+		name, val, err := unionBranch(v)
+		if err != nil {
+			return err
+		}
+		branch, _ := findUnionBranch(s, name)
+		if branch == nil {
+			return fmt.Errorf("unknown union branch: %s", name)
+		}
+		if branch.Type() == avro.Null {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('{')
+		writeJSONString(buf, name)
+		buf.WriteByte(':')
+		if err := writeAvroJSON(buf, branch, val); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("unexpected schema type: %s", schema.Type())
+	}
+	return nil
+}
+
+func writeJSONString(buf *bytes.Buffer, str string) {
+	b, _ := json.Marshal(str)
+	buf.Write(b)
+}
+
+func writeJSONFloat(buf *bytes.Buffer, f float64, typ avro.Type) {
+	switch {
+	case math.IsNaN(f):
+		buf.WriteString(`"NaN"`)
+	case math.IsInf(f, 1):
+		buf.WriteString(`"Infinity"`)
+	case math.IsInf(f, -1):
+		buf.WriteString(`"-Infinity"`)
+	case typ == avro.Float:
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, 32))
+	default:
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+}
+
+func latin1String(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
@@ -0,0 +1,132 @@
+package avroturf_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func TestMarshalAvroJSON(t *testing.T) {
+	schema := mustParse(t, `
+		{
+			"type": "record",
+			"name": "TestRecord",
+			"namespace": "com.example",
+			"fields": [
+				{"name": "str", "type": "string"},
+				{"name": "num", "type": "long"},
+				{"name": "ratio", "type": "double"},
+				{"name": "raw", "type": "bytes"},
+				{"name": "id", "type": {"type": "fixed", "name": "ID", "size": 2}},
+				{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN"]}},
+				{"name": "opt", "type": ["null", "int"]},
+				{"name": "child", "type": ["null", {"type": "record", "name": "Child", "fields": [{"name": "ok", "type": "boolean"}]}]},
+				{"name": "tags", "type": {"type": "array", "items": "string"}},
+				{"name": "attrs", "type": {"type": "map", "values": "float"}}
+			]
+		}
+	`)
+	v := map[string]interface{}{
+		"str":   "hoge",
+		"num":   int64(42),
+		"ratio": 0.5,
+		"raw":   []byte{0x00, 0xff},
+		"id":    []byte{'a', 'b'},
+		"color": "GREEN",
+		"opt":   map[string]interface{}{"int": int32(3)},
+		"child": map[string]interface{}{"com.example.Child": map[string]interface{}{"ok": true}},
+		"tags":  []interface{}{"a", "b"},
+		"attrs": map[string]interface{}{"y": float32(1.5), "x": float32(2)},
+	}
+	b, err := avroturf.MarshalAvroJSON(schema, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"str":"hoge","num":42,"ratio":0.5,"raw":"\u0000ÿ","id":"ab","color":"GREEN","opt":{"int":3},"child":{"com.example.Child":{"ok":true}},"tags":["a","b"],"attrs":{"x":2,"y":1.5}}`
+	if string(b) != expected {
+		t.Errorf("expected:\n  %s but got:\n  %s", expected, b)
+	}
+
+	v["opt"] = nil
+	v["color"] = 1
+	_, err = avroturf.MarshalAvroJSON(schema, v)
+	if err == nil || err.Error() != "com.example.TestRecord.color: expected string for enum com.example.Color but got int" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestUnmarshalAvroJSON(t *testing.T) {
+	schema := mustParse(t, `
+		{
+			"type": "record",
+			"name": "TestRecord",
+			"fields": [
+				{"name": "str", "type": "string"},
+				{"name": "num", "type": "long"},
+				{"name": "raw", "type": "bytes"},
+				{"name": "opt", "type": ["null", "int"]},
+				{"name": "none", "type": ["null", "int"]},
+				{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+				{"name": "at", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+				{"name": "price", "type": {"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}},
+				{"name": "ratio", "type": "float"},
+				{"name": "defaulted", "type": "string", "default": "def"}
+			]
+		}
+	`)
+	v, err := avroturf.UnmarshalAvroJSON(schema, []byte(`
+		{
+			"str": "hoge",
+			"num": 9007199254740993,
+			"raw": "\u0000ÿ",
+			"opt": {"int": 3},
+			"none": null,
+			"day": "1970-01-03",
+			"at": "1970-01-01T00:00:01.5Z",
+			"price": -1.5,
+			"ratio": "NaN"
+		}
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	obj := v.(map[string]interface{})
+	ratio := obj["ratio"].(float32)
+	if !math.IsNaN(float64(ratio)) {
+		t.Errorf("expected NaN but got %v", ratio)
+	}
+	delete(obj, "ratio")
+	expected := map[string]interface{}{
+		"str":       "hoge",
+		"num":       int64(9007199254740993),
+		"raw":       []byte{0x00, 0xff},
+		"opt":       map[string]interface{}{"int": int32(3)},
+		"none":      nil,
+		"day":       int32(2),
+		"at":        int64(1500),
+		"price":     []byte{0xff, 0x6a},
+		"defaulted": "def",
+	}
+	if !reflect.DeepEqual(expected, obj) {
+		t.Errorf("expected %#v but got %#v", expected, obj)
+	}
+}
+
+func TestFailUnmarshalAvroJSON(t *testing.T) {
+	schema := mustParse(t, `{"type": "record", "name": "TestRecord", "fields": [{"name": "opt", "type": ["null", "int"]}]}`)
+	tests := []string{
+		`{}`,
+		`{"opt": 3}`,
+		`{"opt": {"string": "a"}}`,
+		`{"opt": {"int": 3.5}}`,
+		`{"opt": null} {}`,
+	}
+	for _, test := range tests {
+		_, err := avroturf.UnmarshalAvroJSON(schema, []byte(test))
+		if err == nil {
+			t.Errorf("%s: expected error but got nil", test)
+		}
+	}
+}
@@ -0,0 +1,623 @@
+package avroturfgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hamba/avro"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "https": true, "id": true, "ip": true, "json": true,
+	"sql": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+type generator struct {
+	pkg      string
+	imports  map[string]bool
+	goNames  map[string]string
+	fullName map[string]string
+	defined  map[string]string
+	unions   map[*avro.UnionSchema]string
+	tagged   []*avro.UnionSchema
+	records  []*avro.RecordSchema
+	glued    map[string]bool
+	decls    []string
+	consts   []string
+}
+
+func Generate(store *avroturf.SchemaStore, packageName string) ([]byte, error) {
+	names, err := store.FullNames()
+	if err != nil {
+		return nil, err
+	}
+	schemas := make([]*avroturf.Schema, 0, len(names))
+	for _, name := range names {
+		schema, err := store.Find(name, "")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		schemas = append(schemas, schema)
+	}
+	return GenerateSchemas(packageName, schemas...)
+}
+
+func GenerateSchemas(packageName string, schemas ...*avroturf.Schema) ([]byte, error) {
+	g := &generator{
+		pkg:      packageName,
+		imports:  map[string]bool{},
+		goNames:  map[string]string{},
+		fullName: map[string]string{},
+		defined:  map[string]string{},
+		unions:   map[*avro.UnionSchema]string{},
+		glued:    map[string]bool{},
+	}
+	exported := map[string]bool{}
+	for _, schema := range schemas {
+		named, ok := schema.Schema.(avro.NamedSchema)
+		if !ok {
+			continue
+		}
+		name, err := g.goType(named, "")
+		if err != nil {
+			return nil, err
+		}
+		if g.fullName[named.FullName()] != name || exported[name] {
+			continue
+		}
+		exported[name] = true
+		g.consts = append(g.consts, fmt.Sprintf("%sSchema = %s", name, strconv.Quote(schema.String())))
+	}
+	g.glue()
+	return g.source()
+}
+
+func (g *generator) source() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by avroturf. DO NOT EDIT.\n\npackage %s\n\n", g.pkg)
+	if len(g.imports) > 0 {
+		var std, others []string
+		for path := range g.imports {
+			if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+				others = append(others, strconv.Quote(path))
+			} else {
+				std = append(std, strconv.Quote(path))
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(others)
+		groups := []string{}
+		for _, group := range [][]string{std, others} {
+			if len(group) > 0 {
+				groups = append(groups, strings.Join(group, "\n"))
+			}
+		}
+		fmt.Fprintf(&buf, "import (\n%s\n)\n\n", strings.Join(groups, "\n\n"))
+	}
+	if len(g.consts) > 0 {
+		fmt.Fprintf(&buf, "const (\n%s\n)\n\n", strings.Join(g.consts, "\n"))
+	}
+	for _, decl := range g.decls {
+		buf.WriteString(decl)
+		buf.WriteString("\n")
+	}
+	return format.Source(buf.Bytes())
+}
+
+func (g *generator) goType(schema avro.Schema, hint string) (string, error) {
+	switch s := schema.(type) {
+	case *avro.PrimitiveSchema:
+		return g.primitiveType(s), nil
+	case *avro.RefSchema:
+		return g.goType(s.Schema(), hint)
+	case *avro.ArraySchema:
+		items, err := g.goType(s.Items(), hint+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + items, nil
+	case *avro.MapSchema:
+		values, err := g.goType(s.Values(), hint+"Value")
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + values, nil
+	case *avro.UnionSchema:
+		return g.unionType(s, hint)
+	case *avro.RecordSchema, *avro.EnumSchema, *avro.FixedSchema:
+		return g.namedType(s.(avro.NamedSchema))
+	}
+	return "", fmt.Errorf("unsupported schema type %s", schema.Type())
+}
+
+func (g *generator) primitiveType(s *avro.PrimitiveSchema) string {
+	if logical := s.Logical(); logical != nil {
+		switch logical.Type() {
+		case avro.Date, avro.TimestampMillis, avro.TimestampMicros:
+			g.imports["time"] = true
+			return "time.Time"
+		case avro.TimeMillis, avro.TimeMicros:
+			g.imports["time"] = true
+			return "time.Duration"
+		}
+	}
+	switch s.Type() {
+	case avro.Boolean:
+		return "bool"
+	case avro.Int:
+		return "int32"
+	case avro.Long:
+		return "int64"
+	case avro.Float:
+		return "float32"
+	case avro.Double:
+		return "float64"
+	case avro.String:
+		return "string"
+	case avro.Bytes:
+		return "[]byte"
+	}
+	return "interface{}"
+}
+
+func (g *generator) unionType(s *avro.UnionSchema, hint string) (string, error) {
+	if s.Nullable() {
+		_, i := s.Indices()
+		typ, err := g.goType(s.Types()[i], hint)
+		if err != nil {
+			return "", err
+		}
+		return "*" + typ, nil
+	}
+	if name, hit := g.unions[s]; hit {
+		return name, nil
+	}
+	if other, hit := g.goNames[hint]; hit {
+		return "", fmt.Errorf("%s and a union both map to Go type %s", other, hint)
+	}
+	g.goNames[hint] = "union " + hint
+	g.unions[s] = hint
+	g.tagged = append(g.tagged, s)
+
+	i := len(g.decls)
+	g.decls = append(g.decls, "")
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "type %s struct {\n", hint)
+	fields := map[string]string{}
+	for _, t := range unionBranches(s) {
+		field := branchField(t)
+		typ, err := g.goType(t, hint+field)
+		if err != nil {
+			return "", err
+		}
+		if other, hit := fields[field]; hit {
+			return "", fmt.Errorf("union branches %s and %s both map to Go field %s", other, branchName(t), field)
+		}
+		fields[field] = branchName(t)
+		fmt.Fprintf(&buf, "%s *%s\n", field, typ)
+	}
+	buf.WriteString("}\n")
+	g.decls[i] = buf.String()
+	return hint, nil
+}
+
+func derefSchema(schema avro.Schema) avro.Schema {
+	if ref, ok := schema.(*avro.RefSchema); ok {
+		return ref.Schema()
+	}
+	return schema
+}
+
+func branchField(s avro.Schema) string {
+	switch s := s.(type) {
+	case avro.NamedSchema:
+		return exportedName(s.Name())
+	case *avro.PrimitiveSchema:
+		if logical := s.Logical(); logical != nil {
+			return exportedName(string(logical.Type()))
+		}
+	}
+	return exportedName(string(s.Type()))
+}
+
+func branchName(s avro.Schema) string {
+	if named, ok := s.(avro.NamedSchema); ok {
+		return named.FullName()
+	}
+	return string(s.Type())
+}
+
+func unionBranches(s *avro.UnionSchema) []avro.Schema {
+	var branches []avro.Schema
+	for _, t := range s.Types() {
+		if t = derefSchema(t); t.Type() != avro.Null {
+			branches = append(branches, t)
+		}
+	}
+	return branches
+}
+
+func unexportedName(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+func (g *generator) glue() {
+	for changed := true; changed; {
+		changed = false
+		for _, s := range g.records {
+			if g.glued[s.FullName()] {
+				continue
+			}
+			for _, field := range s.Fields() {
+				if g.needsGlue(field.Type()) {
+					g.glued[s.FullName()] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	reached := map[string]bool{}
+	for _, s := range g.records {
+		if g.glued[s.FullName()] {
+			reach(s, reached)
+		}
+	}
+	for _, s := range g.records {
+		if !reached[s.FullName()] {
+			continue
+		}
+		name := g.fullName[s.FullName()]
+		g.decls = append(g.decls, g.recordDatum(name, s))
+		if g.glued[s.FullName()] {
+			g.decls = append(g.decls, recordGlue(name))
+		}
+	}
+	for _, s := range g.tagged {
+		g.decls = append(g.decls, g.unionDatum(g.unions[s], s))
+	}
+	if len(g.tagged) > 0 {
+		g.imports["github.com/hamba/avro"] = true
+		g.imports["github.com/wanabe/avroturf-go"] = true
+	}
+}
+
+func (g *generator) needsGlue(schema avro.Schema) bool {
+	switch s := derefSchema(schema).(type) {
+	case *avro.RecordSchema:
+		return g.glued[s.FullName()]
+	case *avro.ArraySchema:
+		return g.needsGlue(s.Items())
+	case *avro.MapSchema:
+		return g.needsGlue(s.Values())
+	case *avro.UnionSchema:
+		if _, hit := g.unions[s]; hit {
+			return true
+		}
+		_, i := s.Indices()
+		return g.needsGlue(s.Types()[i])
+	}
+	return false
+}
+
+func reach(schema avro.Schema, reached map[string]bool) {
+	switch s := derefSchema(schema).(type) {
+	case *avro.RecordSchema:
+		if reached[s.FullName()] {
+			return
+		}
+		reached[s.FullName()] = true
+		for _, field := range s.Fields() {
+			reach(field.Type(), reached)
+		}
+	case *avro.ArraySchema:
+		reach(s.Items(), reached)
+	case *avro.MapSchema:
+		reach(s.Values(), reached)
+	case *avro.UnionSchema:
+		for _, t := range s.Types() {
+			reach(t, reached)
+		}
+	}
+}
+
+func recordGlue(name string) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "func (r %s) MarshalAvro(schema avro.Schema) ([]byte, error) {\nreturn avroturf.MarshalGeneric(schema, r.toDatum())\n}\n\n", name)
+	fmt.Fprintf(&buf, "func (r *%s) UnmarshalAvro(schema avro.Schema, data []byte) error {\nv, err := avroturf.UnmarshalGeneric(schema, data)\nif err != nil {\nreturn err\n}\n*r = %sFromDatum(v)\nreturn nil\n}\n", name, unexportedName(name))
+	return buf.String()
+}
+
+func (g *generator) recordDatum(name string, s *avro.RecordSchema) string {
+	var buf bytes.Buffer
+	to := &converter{g: g, buf: &bytes.Buffer{}}
+	entries := make([]string, 0, len(s.Fields()))
+	for _, field := range s.Fields() {
+		entries = append(entries, fmt.Sprintf("%q: %s,", field.Name(), to.toDatum("r."+exportedName(field.Name()), field.Type())))
+	}
+	fmt.Fprintf(&buf, "func (r %s) toDatum() map[string]interface{} {\n%sreturn map[string]interface{}{\n%s\n}\n}\n\n", name, to.buf.String(), strings.Join(entries, "\n"))
+
+	from := &converter{g: g, buf: &buf}
+	fmt.Fprintf(&buf, "func %sFromDatum(v interface{}) %s {\nm, _ := v.(map[string]interface{})\nvar r %s\n", unexportedName(name), name, name)
+	for _, field := range s.Fields() {
+		fmt.Fprintf(&buf, "r.%s = %s\n", exportedName(field.Name()), from.fromDatum(fmt.Sprintf("m[%q]", field.Name()), field.Type()))
+	}
+	buf.WriteString("return r\n}\n")
+	return buf.String()
+}
+
+func (g *generator) unionDatum(name string, s *avro.UnionSchema) string {
+	var buf bytes.Buffer
+	to := &converter{g: g, buf: &buf}
+	fmt.Fprintf(&buf, "func (u %s) toDatum() interface{} {\nswitch {\n", name)
+	for _, t := range unionBranches(s) {
+		field := "u." + branchField(t)
+		fmt.Fprintf(&buf, "case %s != nil:\n", field)
+		fmt.Fprintf(&buf, "return map[string]interface{}{%q: %s}\n", branchName(t), to.toDatum("*"+field, t))
+	}
+	buf.WriteString("}\nreturn nil\n}\n\n")
+
+	from := &converter{g: g, buf: &buf}
+	fmt.Fprintf(&buf, "func %sFromDatum(v interface{}) %s {\nm, _ := v.(map[string]interface{})\nvar u %s\n", unexportedName(name), name, name)
+	for _, t := range unionBranches(s) {
+		fmt.Fprintf(&buf, "if v, hit := m[%q]; hit {\n", branchName(t))
+		fmt.Fprintf(&buf, "u.%s = %s\n}\n", branchField(t), from.pointerTo(from.fromDatum("v", t)))
+	}
+	buf.WriteString("return u\n}\n")
+	return buf.String()
+}
+
+type converter struct {
+	g   *generator
+	buf *bytes.Buffer
+	n   int
+}
+
+func (c *converter) next() int {
+	c.n++
+	return c.n
+}
+
+func (c *converter) pointerTo(expr string) string {
+	if token.IsIdentifier(expr) {
+		return "&" + expr
+	}
+	x := fmt.Sprintf("x%d", c.next())
+	fmt.Fprintf(c.buf, "%s := %s\n", x, expr)
+	return "&" + x
+}
+
+func (c *converter) toDatum(src string, schema avro.Schema) string {
+	receiver := strings.TrimPrefix(src, "*")
+	switch s := derefSchema(schema).(type) {
+	case *avro.PrimitiveSchema:
+		if logical := s.Logical(); logical != nil {
+			switch logical.Type() {
+			case avro.Date:
+				return fmt.Sprintf("int32(%s.UnixNano() / int64(24*time.Hour))", receiver)
+			case avro.TimestampMillis:
+				return fmt.Sprintf("%s.Unix()*1e3 + int64(%s.Nanosecond()/1e6)", receiver, receiver)
+			case avro.TimestampMicros:
+				return fmt.Sprintf("%s.Unix()*1e6 + int64(%s.Nanosecond()/1e3)", receiver, receiver)
+			case avro.TimeMillis:
+				return fmt.Sprintf("int32(%s / time.Millisecond)", src)
+			case avro.TimeMicros:
+				return fmt.Sprintf("int64(%s / time.Microsecond)", src)
+			}
+		}
+		return src
+	case *avro.EnumSchema:
+		return "string(" + src + ")"
+	case *avro.FixedSchema:
+		if receiver != src {
+			src = "(" + src + ")"
+		}
+		return "append([]byte(nil), " + src + "[:]...)"
+	case *avro.RecordSchema:
+		return receiver + ".toDatum()"
+	case *avro.ArraySchema:
+		n := c.next()
+		fmt.Fprintf(c.buf, "a%d := make([]interface{}, len(%s))\nfor i%d, x%d := range %s {\n", n, src, n, n, src)
+		fmt.Fprintf(c.buf, "a%d[i%d] = %s\n}\n", n, n, c.toDatum(fmt.Sprintf("x%d", n), s.Items()))
+		return fmt.Sprintf("a%d", n)
+	case *avro.MapSchema:
+		n := c.next()
+		fmt.Fprintf(c.buf, "m%d := make(map[string]interface{}, len(%s))\nfor k%d, x%d := range %s {\n", n, src, n, n, src)
+		fmt.Fprintf(c.buf, "m%d[k%d] = %s\n}\n", n, n, c.toDatum(fmt.Sprintf("x%d", n), s.Values()))
+		return fmt.Sprintf("m%d", n)
+	case *avro.UnionSchema:
+		if _, hit := c.g.unions[s]; hit {
+			return receiver + ".toDatum()"
+		}
+		n := c.next()
+		_, i := s.Indices()
+		t := derefSchema(s.Types()[i])
+		fmt.Fprintf(c.buf, "var u%d interface{}\nif %s != nil {\n", n, src)
+		fmt.Fprintf(c.buf, "u%d = map[string]interface{}{%q: %s}\n}\n", n, branchName(t), c.toDatum("*"+src, t))
+		return fmt.Sprintf("u%d", n)
+	}
+	return src
+}
+
+func (c *converter) fromDatum(v string, schema avro.Schema) string {
+	typ, _ := c.g.goType(schema, "")
+	switch s := derefSchema(schema).(type) {
+	case *avro.PrimitiveSchema:
+		n := c.next()
+		fmt.Fprintf(c.buf, "p%d, _ := %s.(%s)\n", n, v, datumType(s.Type()))
+		if logical := s.Logical(); logical != nil {
+			switch logical.Type() {
+			case avro.Date:
+				return fmt.Sprintf("time.Unix(0, int64(p%d)*int64(24*time.Hour)).UTC()", n)
+			case avro.TimestampMillis:
+				return fmt.Sprintf("time.Unix(p%d/1e3, p%d%%1e3*1e6).UTC()", n, n)
+			case avro.TimestampMicros:
+				return fmt.Sprintf("time.Unix(p%d/1e6, p%d%%1e6*1e3).UTC()", n, n)
+			case avro.TimeMillis:
+				return fmt.Sprintf("time.Duration(p%d) * time.Millisecond", n)
+			case avro.TimeMicros:
+				return fmt.Sprintf("time.Duration(p%d) * time.Microsecond", n)
+			}
+		}
+		return fmt.Sprintf("p%d", n)
+	case *avro.EnumSchema:
+		n := c.next()
+		fmt.Fprintf(c.buf, "p%d, _ := %s.(string)\n", n, v)
+		return fmt.Sprintf("%s(p%d)", typ, n)
+	case *avro.FixedSchema:
+		n := c.next()
+		fmt.Fprintf(c.buf, "p%d, _ := %s.([]byte)\nvar f%d %s\ncopy(f%d[:], p%d)\n", n, v, n, typ, n, n)
+		return fmt.Sprintf("f%d", n)
+	case *avro.RecordSchema:
+		return fmt.Sprintf("%sFromDatum(%s)", unexportedName(typ), v)
+	case *avro.ArraySchema:
+		n := c.next()
+		fmt.Fprintf(c.buf, "a%d, _ := %s.([]interface{})\nvar s%d %s\nfor _, x%d := range a%d {\n", n, v, n, typ, n, n)
+		fmt.Fprintf(c.buf, "s%d = append(s%d, %s)\n}\n", n, n, c.fromDatum(fmt.Sprintf("x%d", n), s.Items()))
+		return fmt.Sprintf("s%d", n)
+	case *avro.MapSchema:
+		n := c.next()
+		fmt.Fprintf(c.buf, "m%d, _ := %s.(map[string]interface{})\nw%d := make(%s, len(m%d))\nfor k%d, x%d := range m%d {\n", n, v, n, typ, n, n, n, n)
+		fmt.Fprintf(c.buf, "w%d[k%d] = %s\n}\n", n, n, c.fromDatum(fmt.Sprintf("x%d", n), s.Values()))
+		return fmt.Sprintf("w%d", n)
+	case *avro.UnionSchema:
+		if name, hit := c.g.unions[s]; hit {
+			return fmt.Sprintf("%sFromDatum(%s)", unexportedName(name), v)
+		}
+		n := c.next()
+		_, i := s.Indices()
+		t := derefSchema(s.Types()[i])
+		fmt.Fprintf(c.buf, "var u%d %s\nif m%d, ok := %s.(map[string]interface{}); ok {\n", n, typ, n, v)
+		fmt.Fprintf(c.buf, "u%d = %s\n}\n", n, c.pointerTo(c.fromDatum(fmt.Sprintf("m%d[%q]", n, branchName(t)), t)))
+		return fmt.Sprintf("u%d", n)
+	}
+	return v
+}
+
+func datumType(typ avro.Type) string {
+	switch typ {
+	case avro.Boolean:
+		return "bool"
+	case avro.Int:
+		return "int32"
+	case avro.Long:
+		return "int64"
+	case avro.Float:
+		return "float32"
+	case avro.Double:
+		return "float64"
+	case avro.String:
+		return "string"
+	case avro.Bytes:
+		return "[]byte"
+	}
+	return "interface{}"
+}
+
+func (g *generator) namedType(s avro.NamedSchema) (string, error) {
+	if name, hit := g.fullName[s.FullName()]; hit {
+		if g.defined[s.FullName()] != s.String() {
+			return "", fmt.Errorf("conflicting definitions of %s", s.FullName())
+		}
+		return name, nil
+	}
+	if fixed, ok := s.(*avro.FixedSchema); ok && fixed.Logical() != nil {
+		return fmt.Sprintf("[%d]byte", fixed.Size()), nil
+	}
+	name := exportedName(s.Name())
+	if other, hit := g.goNames[name]; hit {
+		return "", fmt.Errorf("%s and %s both map to Go type %s", other, s.FullName(), name)
+	}
+	g.goNames[name] = s.FullName()
+	g.fullName[s.FullName()] = name
+	g.defined[s.FullName()] = s.String()
+
+	i := len(g.decls)
+	g.decls = append(g.decls, "")
+	var decl string
+	var err error
+	switch s := s.(type) {
+	case *avro.RecordSchema:
+		g.records = append(g.records, s)
+		decl, err = g.recordDecl(name, s)
+	case *avro.EnumSchema:
+		decl = enumDecl(name, s)
+	case *avro.FixedSchema:
+		decl = fmt.Sprintf("type %s [%d]byte\n", name, s.Size())
+	}
+	if err != nil {
+		return "", err
+	}
+	g.decls[i] = decl
+	return name, nil
+}
+
+func (g *generator) recordDecl(name string, s *avro.RecordSchema) (string, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "type %s struct {\n", name)
+	seen := map[string]string{}
+	for _, field := range s.Fields() {
+		fieldName := exportedName(field.Name())
+		if other, hit := seen[fieldName]; hit {
+			return "", fmt.Errorf("%s: fields %s and %s both map to Go field %s", s.FullName(), other, field.Name(), fieldName)
+		}
+		seen[fieldName] = field.Name()
+		typ, err := g.goType(field.Type(), name+fieldName)
+		if err != nil {
+			return "", fmt.Errorf("%s.%s: %v", s.FullName(), field.Name(), err)
+		}
+		fmt.Fprintf(&buf, "%s %s `avro:%q`\n", fieldName, typ, field.Name())
+	}
+	buf.WriteString("}\n")
+	return buf.String(), nil
+}
+
+func enumDecl(name string, s *avro.EnumSchema) string {
+	var buf bytes.Buffer
+	constants := make([]string, 0, len(s.Symbols()))
+	fmt.Fprintf(&buf, "type %s string\n\nconst (\n", name)
+	for _, symbol := range s.Symbols() {
+		constant := name + exportedName(symbolName(symbol))
+		constants = append(constants, constant)
+		fmt.Fprintf(&buf, "%s %s = %q\n", constant, name, symbol)
+	}
+	fmt.Fprintf(&buf, ")\n\nfunc (e %s) String() string {\nreturn string(e)\n}\n\n", name)
+	fmt.Fprintf(&buf, "func (e %s) Valid() bool {\nswitch e {\ncase %s:\nreturn true\n}\nreturn false\n}\n", name, strings.Join(constants, ", "))
+	return buf.String()
+}
+
+func symbolName(symbol string) string {
+	if strings.ToUpper(symbol) == symbol {
+		return strings.ToLower(symbol)
+	}
+	return symbol
+}
+
+func exportedName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, part := range parts {
+		if initialisms[part] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
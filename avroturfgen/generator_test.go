@@ -0,0 +1,125 @@
+package avroturfgen_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+	"github.com/wanabe/avroturf-go/avroturfgen"
+)
+
+func mustParse(t *testing.T, s string) *avroturf.Schema {
+	t.Helper()
+	schema, err := avroturf.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestGenerate(t *testing.T) {
+	src, err := avroturfgen.Generate(avroturf.NewSchemaStore("testdata"), "example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ioutil.ReadFile("internal/example/example_avro.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != string(expected) {
+		t.Errorf("internal/example/example_avro.go is out of date, run go generate:\n%s", src)
+	}
+}
+
+func TestGenerateSchemas(t *testing.T) {
+	node := mustParse(t, `{"type":"record","name":"tree_node","namespace":"x","fields":[
+		{"name":"value","type":"string"},
+		{"name":"next","type":["tree_node","null"]},
+		{"name":"children","type":{"type":"array","items":"tree_node"}},
+		{"name":"hash","type":{"type":"fixed","name":"hash","size":4,"logicalType":"decimal","precision":4}}
+	]}`)
+	src, err := avroturfgen.GenerateSchemas("x", node, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"package x\n",
+		"type TreeNode struct {",
+		"Next     *TreeNode  `avro:\"next\"`",
+		"Children []TreeNode `avro:\"children\"`",
+		"Hash     [4]byte    `avro:\"hash\"`",
+		`{\"name\":\"next\",\"type\":[\"tree_node\",\"null\"]}`,
+	} {
+		if !strings.Contains(string(src), expected) {
+			t.Errorf("expected generated code to contain %q:\n%s", expected, src)
+		}
+	}
+	if strings.Count(string(src), "type TreeNode struct") != 1 {
+		t.Errorf("expected TreeNode to be generated once:\n%s", src)
+	}
+}
+
+func TestGenerateSchemasConflicts(t *testing.T) {
+	for _, tc := range []struct {
+		schemas  []string
+		expected string
+	}{
+		{
+			[]string{
+				`{"type":"record","name":"Rec","fields":[{"name":"a","type":"string"}]}`,
+				`{"type":"record","name":"Rec","fields":[{"name":"b","type":"string"}]}`,
+			},
+			"conflicting definitions of Rec",
+		},
+		{
+			[]string{
+				`{"type":"record","name":"Rec","namespace":"a","fields":[]}`,
+				`{"type":"record","name":"Rec","namespace":"b","fields":[]}`,
+			},
+			"a.Rec and b.Rec both map to Go type Rec",
+		},
+		{
+			[]string{`{"type":"record","name":"Rec","fields":[{"name":"user_id","type":"long"},{"name":"userID","type":"long"}]}`},
+			"Rec: fields user_id and userID both map to Go field UserID",
+		},
+		{
+			[]string{`{"type":"record","name":"Rec","fields":[{"name":"kind","type":["int","string"]},{"name":"other","type":{"type":"record","name":"RecKind","fields":[]}}]}`},
+			"Rec.other: union RecKind and RecKind both map to Go type RecKind",
+		},
+	} {
+		schemas := make([]*avroturf.Schema, len(tc.schemas))
+		for i, s := range tc.schemas {
+			schemas[i] = mustParse(t, s)
+		}
+		_, err := avroturfgen.GenerateSchemas("x", schemas...)
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("expected %q but got %v", tc.expected, err)
+		}
+	}
+}
+
+func TestGenerateSchemasUnions(t *testing.T) {
+	node := mustParse(t, `{"type":"record","name":"Node","fields":[
+		{"name":"label","type":["null","int","string"]},
+		{"name":"parent","type":["null","Node"]},
+		{"name":"children","type":{"type":"map","values":"Node"}},
+		{"name":"link","type":["string","Node"]}
+	]}`)
+	src, err := avroturfgen.GenerateSchemas("x", node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"type NodeLabel struct {\n\tInt    *int32\n\tString *string\n}",
+		"type NodeLink struct {\n\tString *string\n\tNode   *Node\n}",
+		`return map[string]interface{}{"Node": u.Node.toDatum()}`,
+		`if m1, ok := m["parent"].(map[string]interface{}); ok {`,
+		"func (r Node) MarshalAvro(schema avro.Schema) ([]byte, error) {",
+		"func (r *Node) UnmarshalAvro(schema avro.Schema, data []byte) error {",
+	} {
+		if !strings.Contains(string(src), expected) {
+			t.Errorf("expected generated code to contain %q:\n%s", expected, src)
+		}
+	}
+}
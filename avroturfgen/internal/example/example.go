@@ -0,0 +1,3 @@
+package example
+
+//go:generate go run github.com/wanabe/avroturf-go/cmd/avroturf generate -schemas ../../testdata -out example_avro.go
@@ -0,0 +1,370 @@
+// Code generated by avroturf. DO NOT EDIT.
+
+package example
+
+import (
+	"time"
+
+	"github.com/hamba/avro"
+	"github.com/wanabe/avroturf-go"
+)
+
+const (
+	EventSchema = "{\"fields\":[{\"name\":\"name\",\"type\":\"string\"},{\"name\":\"priority\",\"type\":{\"name\":\"Priority\",\"symbols\":[\"LOW\",\"HIGH\"],\"type\":\"enum\"}},{\"name\":\"price\",\"type\":{\"logicalType\":\"decimal\",\"precision\":8,\"scale\":2,\"type\":\"bytes\"}},{\"name\":\"session_uuid\",\"type\":{\"logicalType\":\"uuid\",\"type\":\"string\"}},{\"name\":\"attributes\",\"type\":{\"type\":\"map\",\"values\":\"long\"}}],\"name\":\"Event\",\"namespace\":\"com.example\",\"type\":\"record\"}"
+	UserSchema  = "{\"fields\":[{\"name\":\"id\",\"type\":\"long\"},{\"name\":\"user_name\",\"type\":\"string\"},{\"default\":null,\"name\":\"email\",\"type\":[\"null\",\"string\"]},{\"name\":\"age\",\"type\":\"int\"},{\"name\":\"score\",\"type\":\"float\"},{\"name\":\"balance\",\"type\":\"double\"},{\"name\":\"active\",\"type\":\"boolean\"},{\"name\":\"avatar\",\"type\":\"bytes\"},{\"name\":\"status\",\"type\":{\"name\":\"Status\",\"symbols\":[\"ACTIVE\",\"SUSPENDED\",\"DELETED_BY_ADMIN\"],\"type\":\"enum\"}},{\"name\":\"checksum\",\"type\":{\"name\":\"MD5\",\"size\":16,\"type\":\"fixed\"}},{\"name\":\"tags\",\"type\":{\"items\":\"string\",\"type\":\"array\"}},{\"default\":null,\"name\":\"address\",\"type\":[\"null\",{\"fields\":[{\"name\":\"street\",\"type\":\"string\"},{\"name\":\"zip_code\",\"type\":\"string\"}],\"name\":\"Address\",\"type\":\"record\"}]},{\"name\":\"history\",\"type\":{\"items\":\"Address\",\"type\":\"array\"}},{\"name\":\"created_at\",\"type\":{\"logicalType\":\"timestamp-millis\",\"type\":\"long\"}},{\"name\":\"birthday\",\"type\":{\"logicalType\":\"date\",\"type\":\"int\"}},{\"name\":\"wake_up\",\"type\":{\"logicalType\":\"time-millis\",\"type\":\"int\"}},{\"name\":\"payload\",\"type\":[\"string\",\"long\",\"Status\",\"Address\",{\"fields\":[{\"name\":\"code\",\"type\":\"string\"},{\"name\":\"discounts\",\"type\":{\"items\":[\"long\",\"double\"],\"type\":\"array\"}}],\"name\":\"Coupon\",\"type\":\"record\"}]},{\"default\":null,\"name\":\"note\",\"type\":[\"null\",\"string\",\"long\"]}],\"name\":\"User\",\"namespace\":\"com.example\",\"type\":\"record\"}"
+)
+
+type Event struct {
+	Name        string           `avro:"name"`
+	Priority    Priority         `avro:"priority"`
+	Price       []byte           `avro:"price"`
+	SessionUUID string           `avro:"session_uuid"`
+	Attributes  map[string]int64 `avro:"attributes"`
+}
+
+type Priority string
+
+const (
+	PriorityLow  Priority = "LOW"
+	PriorityHigh Priority = "HIGH"
+)
+
+func (e Priority) String() string {
+	return string(e)
+}
+
+func (e Priority) Valid() bool {
+	switch e {
+	case PriorityLow, PriorityHigh:
+		return true
+	}
+	return false
+}
+
+type User struct {
+	ID        int64         `avro:"id"`
+	UserName  string        `avro:"user_name"`
+	Email     *string       `avro:"email"`
+	Age       int32         `avro:"age"`
+	Score     float32       `avro:"score"`
+	Balance   float64       `avro:"balance"`
+	Active    bool          `avro:"active"`
+	Avatar    []byte        `avro:"avatar"`
+	Status    Status        `avro:"status"`
+	Checksum  MD5           `avro:"checksum"`
+	Tags      []string      `avro:"tags"`
+	Address   *Address      `avro:"address"`
+	History   []Address     `avro:"history"`
+	CreatedAt time.Time     `avro:"created_at"`
+	Birthday  time.Time     `avro:"birthday"`
+	WakeUp    time.Duration `avro:"wake_up"`
+	Payload   UserPayload   `avro:"payload"`
+	Note      UserNote      `avro:"note"`
+}
+
+type Status string
+
+const (
+	StatusActive         Status = "ACTIVE"
+	StatusSuspended      Status = "SUSPENDED"
+	StatusDeletedByAdmin Status = "DELETED_BY_ADMIN"
+)
+
+func (e Status) String() string {
+	return string(e)
+}
+
+func (e Status) Valid() bool {
+	switch e {
+	case StatusActive, StatusSuspended, StatusDeletedByAdmin:
+		return true
+	}
+	return false
+}
+
+type MD5 [16]byte
+
+type Address struct {
+	Street  string `avro:"street"`
+	ZipCode string `avro:"zip_code"`
+}
+
+type UserPayload struct {
+	String  *string
+	Long    *int64
+	Status  *Status
+	Address *Address
+	Coupon  *Coupon
+}
+
+type Coupon struct {
+	Code      string                `avro:"code"`
+	Discounts []CouponDiscountsItem `avro:"discounts"`
+}
+
+type CouponDiscountsItem struct {
+	Long   *int64
+	Double *float64
+}
+
+type UserNote struct {
+	String *string
+	Long   *int64
+}
+
+func (r User) toDatum() map[string]interface{} {
+	var u1 interface{}
+	if r.Email != nil {
+		u1 = map[string]interface{}{"string": *r.Email}
+	}
+	a2 := make([]interface{}, len(r.Tags))
+	for i2, x2 := range r.Tags {
+		a2[i2] = x2
+	}
+	var u3 interface{}
+	if r.Address != nil {
+		u3 = map[string]interface{}{"com.example.Address": r.Address.toDatum()}
+	}
+	a4 := make([]interface{}, len(r.History))
+	for i4, x4 := range r.History {
+		a4[i4] = x4.toDatum()
+	}
+	return map[string]interface{}{
+		"id":         r.ID,
+		"user_name":  r.UserName,
+		"email":      u1,
+		"age":        r.Age,
+		"score":      r.Score,
+		"balance":    r.Balance,
+		"active":     r.Active,
+		"avatar":     r.Avatar,
+		"status":     string(r.Status),
+		"checksum":   append([]byte(nil), r.Checksum[:]...),
+		"tags":       a2,
+		"address":    u3,
+		"history":    a4,
+		"created_at": r.CreatedAt.Unix()*1e3 + int64(r.CreatedAt.Nanosecond()/1e6),
+		"birthday":   int32(r.Birthday.UnixNano() / int64(24*time.Hour)),
+		"wake_up":    int32(r.WakeUp / time.Millisecond),
+		"payload":    r.Payload.toDatum(),
+		"note":       r.Note.toDatum(),
+	}
+}
+
+func userFromDatum(v interface{}) User {
+	m, _ := v.(map[string]interface{})
+	var r User
+	p1, _ := m["id"].(int64)
+	r.ID = p1
+	p2, _ := m["user_name"].(string)
+	r.UserName = p2
+	var u3 *string
+	if m3, ok := m["email"].(map[string]interface{}); ok {
+		p4, _ := m3["string"].(string)
+		u3 = &p4
+	}
+	r.Email = u3
+	p5, _ := m["age"].(int32)
+	r.Age = p5
+	p6, _ := m["score"].(float32)
+	r.Score = p6
+	p7, _ := m["balance"].(float64)
+	r.Balance = p7
+	p8, _ := m["active"].(bool)
+	r.Active = p8
+	p9, _ := m["avatar"].([]byte)
+	r.Avatar = p9
+	p10, _ := m["status"].(string)
+	r.Status = Status(p10)
+	p11, _ := m["checksum"].([]byte)
+	var f11 MD5
+	copy(f11[:], p11)
+	r.Checksum = f11
+	a12, _ := m["tags"].([]interface{})
+	var s12 []string
+	for _, x12 := range a12 {
+		p13, _ := x12.(string)
+		s12 = append(s12, p13)
+	}
+	r.Tags = s12
+	var u14 *Address
+	if m14, ok := m["address"].(map[string]interface{}); ok {
+		x15 := addressFromDatum(m14["com.example.Address"])
+		u14 = &x15
+	}
+	r.Address = u14
+	a16, _ := m["history"].([]interface{})
+	var s16 []Address
+	for _, x16 := range a16 {
+		s16 = append(s16, addressFromDatum(x16))
+	}
+	r.History = s16
+	p17, _ := m["created_at"].(int64)
+	r.CreatedAt = time.Unix(p17/1e3, p17%1e3*1e6).UTC()
+	p18, _ := m["birthday"].(int32)
+	r.Birthday = time.Unix(0, int64(p18)*int64(24*time.Hour)).UTC()
+	p19, _ := m["wake_up"].(int32)
+	r.WakeUp = time.Duration(p19) * time.Millisecond
+	r.Payload = userPayloadFromDatum(m["payload"])
+	r.Note = userNoteFromDatum(m["note"])
+	return r
+}
+
+func (r User) MarshalAvro(schema avro.Schema) ([]byte, error) {
+	return avroturf.MarshalGeneric(schema, r.toDatum())
+}
+
+func (r *User) UnmarshalAvro(schema avro.Schema, data []byte) error {
+	v, err := avroturf.UnmarshalGeneric(schema, data)
+	if err != nil {
+		return err
+	}
+	*r = userFromDatum(v)
+	return nil
+}
+
+func (r Address) toDatum() map[string]interface{} {
+	return map[string]interface{}{
+		"street":   r.Street,
+		"zip_code": r.ZipCode,
+	}
+}
+
+func addressFromDatum(v interface{}) Address {
+	m, _ := v.(map[string]interface{})
+	var r Address
+	p1, _ := m["street"].(string)
+	r.Street = p1
+	p2, _ := m["zip_code"].(string)
+	r.ZipCode = p2
+	return r
+}
+
+func (r Coupon) toDatum() map[string]interface{} {
+	a1 := make([]interface{}, len(r.Discounts))
+	for i1, x1 := range r.Discounts {
+		a1[i1] = x1.toDatum()
+	}
+	return map[string]interface{}{
+		"code":      r.Code,
+		"discounts": a1,
+	}
+}
+
+func couponFromDatum(v interface{}) Coupon {
+	m, _ := v.(map[string]interface{})
+	var r Coupon
+	p1, _ := m["code"].(string)
+	r.Code = p1
+	a2, _ := m["discounts"].([]interface{})
+	var s2 []CouponDiscountsItem
+	for _, x2 := range a2 {
+		s2 = append(s2, couponDiscountsItemFromDatum(x2))
+	}
+	r.Discounts = s2
+	return r
+}
+
+func (r Coupon) MarshalAvro(schema avro.Schema) ([]byte, error) {
+	return avroturf.MarshalGeneric(schema, r.toDatum())
+}
+
+func (r *Coupon) UnmarshalAvro(schema avro.Schema, data []byte) error {
+	v, err := avroturf.UnmarshalGeneric(schema, data)
+	if err != nil {
+		return err
+	}
+	*r = couponFromDatum(v)
+	return nil
+}
+
+func (u UserPayload) toDatum() interface{} {
+	switch {
+	case u.String != nil:
+		return map[string]interface{}{"string": *u.String}
+	case u.Long != nil:
+		return map[string]interface{}{"long": *u.Long}
+	case u.Status != nil:
+		return map[string]interface{}{"com.example.Status": string(*u.Status)}
+	case u.Address != nil:
+		return map[string]interface{}{"com.example.Address": u.Address.toDatum()}
+	case u.Coupon != nil:
+		return map[string]interface{}{"com.example.Coupon": u.Coupon.toDatum()}
+	}
+	return nil
+}
+
+func userPayloadFromDatum(v interface{}) UserPayload {
+	m, _ := v.(map[string]interface{})
+	var u UserPayload
+	if v, hit := m["string"]; hit {
+		p1, _ := v.(string)
+		u.String = &p1
+	}
+	if v, hit := m["long"]; hit {
+		p2, _ := v.(int64)
+		u.Long = &p2
+	}
+	if v, hit := m["com.example.Status"]; hit {
+		p3, _ := v.(string)
+		x4 := Status(p3)
+		u.Status = &x4
+	}
+	if v, hit := m["com.example.Address"]; hit {
+		x5 := addressFromDatum(v)
+		u.Address = &x5
+	}
+	if v, hit := m["com.example.Coupon"]; hit {
+		x6 := couponFromDatum(v)
+		u.Coupon = &x6
+	}
+	return u
+}
+
+func (u CouponDiscountsItem) toDatum() interface{} {
+	switch {
+	case u.Long != nil:
+		return map[string]interface{}{"long": *u.Long}
+	case u.Double != nil:
+		return map[string]interface{}{"double": *u.Double}
+	}
+	return nil
+}
+
+func couponDiscountsItemFromDatum(v interface{}) CouponDiscountsItem {
+	m, _ := v.(map[string]interface{})
+	var u CouponDiscountsItem
+	if v, hit := m["long"]; hit {
+		p1, _ := v.(int64)
+		u.Long = &p1
+	}
+	if v, hit := m["double"]; hit {
+		p2, _ := v.(float64)
+		u.Double = &p2
+	}
+	return u
+}
+
+func (u UserNote) toDatum() interface{} {
+	switch {
+	case u.String != nil:
+		return map[string]interface{}{"string": *u.String}
+	case u.Long != nil:
+		return map[string]interface{}{"long": *u.Long}
+	}
+	return nil
+}
+
+func userNoteFromDatum(v interface{}) UserNote {
+	m, _ := v.(map[string]interface{})
+	var u UserNote
+	if v, hit := m["string"]; hit {
+		p1, _ := v.(string)
+		u.String = &p1
+	}
+	if v, hit := m["long"]; hit {
+		p2, _ := v.(int64)
+		u.Long = &p2
+	}
+	return u
+}
@@ -0,0 +1,84 @@
+package example_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wanabe/avroturf-go"
+	"github.com/wanabe/avroturf-go/avroturfgen/internal/example"
+	"github.com/wanabe/avroturf-go/avroturftest"
+)
+
+func TestRoundTrip(t *testing.T) {
+	srv := avroturftest.NewRegistry()
+	defer srv.Close()
+	schema, err := avroturf.Parse(example.UserSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemaID, err := srv.SchemaRegistry().Register("user-value", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email := "alice@example.com"
+	user := example.User{
+		ID:        1,
+		UserName:  "alice",
+		Email:     &email,
+		Age:       30,
+		Score:     1.5,
+		Balance:   12.25,
+		Active:    true,
+		Avatar:    []byte{1, 2, 3},
+		Status:    example.StatusDeletedByAdmin,
+		Checksum:  example.MD5{0xde, 0xad, 0xbe, 0xef},
+		Tags:      []string{"a", "b"},
+		Address:   &example.Address{Street: "Main St", ZipCode: "12345"},
+		History:   []example.Address{{Street: "Old St", ZipCode: "54321"}},
+		CreatedAt: time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC),
+		Birthday:  time.Date(1990, 5, 6, 0, 0, 0, 0, time.UTC),
+		WakeUp:    7 * time.Hour,
+	}
+	amount, rate := int64(500), 0.1
+	status, text, number := example.StatusSuspended, "text", int64(7)
+	coupon := example.Coupon{Code: "WELCOME", Discounts: []example.CouponDiscountsItem{{Long: &amount}, {Double: &rate}}}
+	messaging := avroturf.NewMessagingWithRegistry("", "", srv.SchemaRegistry())
+	payloads := []example.UserPayload{{Coupon: &coupon}, {Status: &status}, {String: &text}, {Long: &number}, {Address: &example.Address{Street: "New St"}}}
+	notes := []example.UserNote{{Long: &number}, {String: &text}, {}}
+	for i, payload := range payloads {
+		user.Payload = payload
+		user.Note = notes[i%len(notes)]
+		data, err := avroturf.EncodeBySchemaAndId(user, schemaID, schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := example.User{}
+		if err := messaging.Decode(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(user, decoded) {
+			t.Errorf("expected %+v but got %+v", user, decoded)
+		}
+	}
+}
+
+func TestRoundTripRejectsEmptyUnion(t *testing.T) {
+	schema, err := avroturf.Parse(example.UserSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := avroturf.EncodeBySchemaAndId(example.User{}, 1, schema); err == nil {
+		t.Error("expected an error for a union without a branch set")
+	}
+}
+
+func TestEnum(t *testing.T) {
+	if !example.StatusSuspended.Valid() || example.Status("UNKNOWN").Valid() {
+		t.Error("unexpected enum validation result")
+	}
+	if example.PriorityHigh.String() != "HIGH" {
+		t.Errorf("expected HIGH but got %s", example.PriorityHigh)
+	}
+}
@@ -0,0 +1,221 @@
+package avroturftest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+type Registry struct {
+	*httptest.Server
+	Backend *avroturf.InMemorySchemaRegistry
+}
+
+func NewRegistry() *Registry {
+	r := &Registry{
+		Backend: &avroturf.InMemorySchemaRegistry{Compatibility: avroturf.CompatibilityBackward},
+	}
+	r.Server = httptest.NewServer(r)
+	return r
+}
+
+func (r *Registry) SchemaRegistry() *avroturf.ConfluentSchemaRegistry {
+	return &avroturf.ConfluentSchemaRegistry{RegistryURL: r.URL, HTTPClient: r.Client()}
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var segments []string
+	for _, s := range strings.Split(strings.Trim(req.URL.EscapedPath(), "/"), "/") {
+		segment, err := url.PathUnescape(s)
+		if err != nil {
+			writeError(w, &avroturf.RegistryError{StatusCode: http.StatusNotFound, ErrorCode: http.StatusNotFound, Message: err.Error()})
+			return
+		}
+		segments = append(segments, segment)
+	}
+	res, err := r.route(req, segments)
+	if err != nil {
+		var registryErr *avroturf.RegistryError
+		if !errors.As(err, &registryErr) {
+			registryErr = &avroturf.RegistryError{StatusCode: http.StatusInternalServerError, ErrorCode: 50001, Message: err.Error()}
+		}
+		writeError(w, registryErr)
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	json.NewEncoder(w).Encode(res)
+}
+
+func (r *Registry) route(req *http.Request, segments []string) (interface{}, error) {
+	b := r.Backend
+	ctx := req.Context()
+	permanent := req.URL.Query().Get("permanent") == "true"
+	route := req.Method + " " + segments[0]
+	switch {
+	case route == "GET subjects" && len(segments) == 1:
+		return b.ListSubjectsContext(ctx)
+	case route == "POST subjects" && len(segments) == 2:
+		schema, _, err := readSchema(req)
+		if err != nil {
+			return nil, err
+		}
+		registered, err := b.LookupSchemaContext(ctx, segments[1], schema)
+		if err != nil {
+			return nil, err
+		}
+		return registeredSchema(registered), nil
+	case route == "DELETE subjects" && len(segments) == 2:
+		return b.DeleteSubjectContext(ctx, segments[1], permanent)
+	case route == "GET subjects" && len(segments) == 3 && segments[2] == "versions":
+		return b.ListVersionsContext(ctx, segments[1])
+	case route == "POST subjects" && len(segments) == 3 && segments[2] == "versions":
+		schema, body, err := readSchema(req)
+		if err != nil {
+			return nil, err
+		}
+		var id uint32
+		if body.ID != 0 {
+			id, err = b.RegisterWithIDContext(ctx, segments[1], schema, body.ID, body.Version)
+		} else {
+			id, err = b.RegisterContext(ctx, segments[1], schema)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]uint32{"id": id}, nil
+	case route == "GET subjects" && len(segments) == 4 && segments[2] == "versions":
+		version, err := parseVersion(segments[3])
+		if err != nil {
+			return nil, err
+		}
+		registered, err := b.FetchSchemaBySubjectVersionContext(ctx, segments[1], version)
+		if err != nil {
+			return nil, err
+		}
+		return registeredSchema(registered), nil
+	case route == "DELETE subjects" && len(segments) == 4 && segments[2] == "versions":
+		version, err := parseVersion(segments[3])
+		if err != nil {
+			return nil, err
+		}
+		return b.DeleteSchemaVersionContext(ctx, segments[1], version, permanent)
+	case route == "GET schemas" && len(segments) == 3 && segments[1] == "ids":
+		id, err := strconv.ParseUint(segments[2], 10, 32)
+		if err != nil {
+			return nil, &avroturf.RegistryError{StatusCode: http.StatusNotFound, ErrorCode: avroturf.ErrorCodeSchemaNotFound, Message: "Schema " + segments[2] + " not found"}
+		}
+		schema, err := b.FetchSchemaContext(ctx, uint32(id))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]string{"schema": schema.String()}, nil
+	case route == "POST compatibility" && len(segments) == 5 && segments[1] == "subjects" && segments[3] == "versions":
+		version, err := parseVersion(segments[4])
+		if err != nil {
+			return nil, err
+		}
+		schema, _, err := readSchema(req)
+		if err != nil {
+			return nil, err
+		}
+		ok, messages, err := b.CheckCompatibilityContext(ctx, segments[2], version, schema)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"is_compatible": ok, "messages": messages}, nil
+	case route == "GET config" && len(segments) <= 2:
+		get := b.GetCompatibilityContext
+		if req.URL.Query().Get("defaultToGlobal") != "true" {
+			get = b.GetSubjectCompatibilityContext
+		}
+		level, err := get(ctx, strings.Join(segments[1:], ""))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]avroturf.CompatibilityLevel{"compatibilityLevel": level}, nil
+	case route == "PUT config" && len(segments) <= 2:
+		body := struct {
+			Compatibility avroturf.CompatibilityLevel `json:"compatibility"`
+		}{}
+		json.NewDecoder(req.Body).Decode(&body)
+		level, err := b.SetCompatibilityContext(ctx, strings.Join(segments[1:], ""), body.Compatibility)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]avroturf.CompatibilityLevel{"compatibility": level}, nil
+	case route == "GET mode" && len(segments) <= 2:
+		mode, err := b.GetModeContext(ctx, strings.Join(segments[1:], ""))
+		if err != nil {
+			return nil, err
+		}
+		return map[string]avroturf.RegistryMode{"mode": mode}, nil
+	case route == "PUT mode" && len(segments) <= 2:
+		body := struct {
+			Mode avroturf.RegistryMode `json:"mode"`
+		}{}
+		json.NewDecoder(req.Body).Decode(&body)
+		mode, err := b.SetModeContext(ctx, strings.Join(segments[1:], ""), body.Mode)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]avroturf.RegistryMode{"mode": mode}, nil
+	case route == "DELETE mode" && len(segments) == 2:
+		mode, err := b.DeleteModeContext(ctx, segments[1])
+		if err != nil {
+			return nil, err
+		}
+		return map[string]avroturf.RegistryMode{"mode": mode}, nil
+	}
+	return nil, &avroturf.RegistryError{StatusCode: http.StatusNotFound, ErrorCode: http.StatusNotFound, Message: "HTTP 404 Not Found"}
+}
+
+type schemaRequest struct {
+	Schema  string `json:"schema"`
+	ID      uint32 `json:"id"`
+	Version int    `json:"version"`
+}
+
+func parseVersion(s string) (int, error) {
+	if s == "latest" {
+		return avroturf.LatestVersion, nil
+	}
+	version, err := strconv.Atoi(s)
+	if err != nil || version <= 0 {
+		return 0, &avroturf.RegistryError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: avroturf.ErrorCodeInvalidVersion, Message: "The specified version '" + s + "' is not a valid version id"}
+	}
+	return version, nil
+}
+
+func readSchema(req *http.Request) (*avroturf.Schema, *schemaRequest, error) {
+	body := &schemaRequest{}
+	err := json.NewDecoder(req.Body).Decode(body)
+	if err != nil {
+		return nil, nil, &avroturf.RegistryError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: avroturf.ErrorCodeInvalidSchema, Message: "Invalid schema: " + err.Error()}
+	}
+	schema, err := avroturf.Parse(body.Schema)
+	if err != nil {
+		return nil, nil, &avroturf.RegistryError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: avroturf.ErrorCodeInvalidSchema, Message: "Invalid schema: " + err.Error()}
+	}
+	return schema, body, nil
+}
+
+func registeredSchema(registered *avroturf.RegisteredSchema) map[string]interface{} {
+	return map[string]interface{}{
+		"subject": registered.Subject,
+		"version": registered.Version,
+		"id":      registered.ID,
+		"schema":  registered.Schema.String(),
+	}
+}
+
+func writeError(w http.ResponseWriter, err *avroturf.RegistryError) {
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	w.WriteHeader(err.StatusCode)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error_code": err.ErrorCode, "message": err.Message})
+}
@@ -0,0 +1,174 @@
+package avroturftest_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+	"github.com/wanabe/avroturf-go/avroturftest"
+)
+
+func mustParse(t *testing.T, s string) *avroturf.Schema {
+	t.Helper()
+	schema, err := avroturf.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestRegistry(t *testing.T) {
+	srv := avroturftest.NewRegistry()
+	defer srv.Close()
+	r := srv.SchemaRegistry()
+
+	v1 := mustParse(t, `{"type":"record","name":"Rec","fields":[{"name":"a","type":"string"}]}`)
+	v2 := mustParse(t, `{"type":"record","name":"Rec","fields":[{"name":"a","type":"string"},{"name":"b","type":"long","default":0}]}`)
+	incompatible := mustParse(t, `{"type":"record","name":"Rec","fields":[{"name":"c","type":"long"}]}`)
+
+	id1, err := r.Register("a/b-value", v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, err := r.Register("a/b-value", v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := r.Register("other-value", v1); err != nil || again != id1 {
+		t.Errorf("expected schema id %d to be shared across subjects but got %d (%v)", id1, again, err)
+	}
+	if id1 != 1 || id2 != 2 {
+		t.Errorf("unexpected ids: %d %d", id1, id2)
+	}
+	_, err = r.Register("a/b-value", incompatible)
+	if !avroturf.IsIncompatibleSchema(err) {
+		t.Errorf("expected incompatible schema error but got %v", err)
+	}
+	ok, messages, err := r.CheckCompatibility("a/b-value", avroturf.LatestVersion, incompatible)
+	if err != nil || ok || len(messages) == 0 {
+		t.Errorf("unexpected compatibility result: %v %v %v", ok, messages, err)
+	}
+
+	subjects, err := r.ListSubjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"a/b-value", "other-value"}; !reflect.DeepEqual(expected, subjects) {
+		t.Errorf("expected %v but got %v", expected, subjects)
+	}
+	versions, err := r.ListVersions("a/b-value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{1, 2}; !reflect.DeepEqual(expected, versions) {
+		t.Errorf("expected %v but got %v", expected, versions)
+	}
+
+	schema, err := r.FetchSchema(id2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.String() != v2.String() {
+		t.Errorf("expected %v but got %v", v2, schema)
+	}
+	latest, err := r.FetchSchemaBySubjectVersion("a/b-value", avroturf.LatestVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Version != 2 || latest.ID != id2 {
+		t.Errorf("unexpected latest: %+v", latest)
+	}
+	looked, err := r.LookupSchema("a/b-value", v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if looked.Version != 1 || looked.ID != id1 {
+		t.Errorf("unexpected lookup result: %+v", looked)
+	}
+
+	if _, err := r.FetchSchema(99); !avroturf.IsSchemaNotFound(err) {
+		t.Errorf("expected schema not found but got %v", err)
+	}
+	if _, err := r.FetchSchemaBySubjectVersion("missing", 1); !avroturf.IsSubjectNotFound(err) {
+		t.Errorf("expected subject not found but got %v", err)
+	}
+	if _, err := r.FetchSchemaBySubjectVersion("a/b-value", 5); !avroturf.IsVersionNotFound(err) {
+		t.Errorf("expected version not found but got %v", err)
+	}
+	if _, err := r.LookupSchema("a/b-value", incompatible); !avroturf.IsSchemaNotFound(err) {
+		t.Errorf("expected schema not found but got %v", err)
+	}
+}
+
+func TestRegistryConfigAndDelete(t *testing.T) {
+	srv := avroturftest.NewRegistry()
+	defer srv.Close()
+	r := srv.SchemaRegistry()
+
+	level, err := r.GetCompatibility("")
+	if err != nil || level != avroturf.CompatibilityBackward {
+		t.Errorf("unexpected global level: %v %v", level, err)
+	}
+	if _, err := r.SetCompatibility("subject1", avroturf.CompatibilityNone); err != nil {
+		t.Fatal(err)
+	}
+	level, err = r.GetCompatibility("subject1")
+	if err != nil || level != avroturf.CompatibilityNone {
+		t.Errorf("unexpected level for a subject without versions: %v %v", level, err)
+	}
+	level, err = r.GetCompatibility("subject2")
+	if err != nil || level != avroturf.CompatibilityBackward {
+		t.Errorf("expected an unconfigured subject to fall back to the global level but got %v %v", level, err)
+	}
+	if _, err := r.Register("subject1", mustParse(t, `"string"`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Register("subject1", mustParse(t, `"long"`)); err != nil {
+		t.Errorf("expected NONE compatibility to accept any schema but got %v", err)
+	}
+	level, err = r.GetCompatibility("subject1")
+	if err != nil || level != avroturf.CompatibilityNone {
+		t.Errorf("unexpected subject level: %v %v", level, err)
+	}
+
+	if _, err := r.DeleteSchemaVersion("subject1", 1, true); err == nil {
+		t.Error("expected permanent delete without soft delete to fail")
+	}
+	if v, err := r.DeleteSchemaVersion("subject1", 1, false); err != nil || v != 1 {
+		t.Errorf("unexpected delete result: %v %v", v, err)
+	}
+	if v, err := r.DeleteSchemaVersion("subject1", 1, true); err != nil || v != 1 {
+		t.Errorf("unexpected permanent delete result: %v %v", v, err)
+	}
+	versions, err := r.DeleteSubject("subject1", false)
+	if err != nil || !reflect.DeepEqual([]int{2}, versions) {
+		t.Errorf("unexpected delete subject result: %v %v", versions, err)
+	}
+	if _, err := r.ListVersions("subject1"); !avroturf.IsSubjectNotFound(err) {
+		t.Errorf("expected subject not found but got %v", err)
+	}
+	if _, err := r.DeleteSubject("subject1", true); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRegistryWithMessaging(t *testing.T) {
+	srv := avroturftest.NewRegistry()
+	defer srv.Close()
+
+	messaging := avroturf.NewMessaging("test-namespace", "../testdata/", srv.URL, avroturf.WithHTTPClient(srv.Client()))
+	type record struct {
+		Str string `avro:"str"`
+	}
+	data, err := messaging.Encode(record{Str: "hoge"}, "test-value", "test-name", "test-namespace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := record{}
+	if err := messaging.Decode(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Str != "hoge" {
+		t.Errorf("expected hoge but got %s", decoded.Str)
+	}
+}
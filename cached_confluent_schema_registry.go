@@ -1,31 +1,163 @@
 package avroturf
 
+import (
+	"context"
+	"fmt"
+)
+
 type CachedConfluentSchemaRegistry struct {
-	Upstream *ConfluentSchemaRegistry
-	Cache    *InMemoryCache
+	Upstream SchemaRegistry
+	Cache    SchemaCache
+	Hooks    Hooks
+
+	fetches flightGroup
+}
+
+func NewCachedSchemaRegistry(upstream SchemaRegistry, caches ...SchemaCache) SchemaRegistry {
+	registry := upstream
+	for i := len(caches) - 1; i >= 0; i-- {
+		registry = &CachedConfluentSchemaRegistry{Upstream: registry, Cache: caches[i]}
+	}
+	return registry
 }
 
 func (r *CachedConfluentSchemaRegistry) FetchSchema(schemaID uint32) (*Schema, error) {
+	return r.FetchSchemaContext(context.Background(), schemaID)
+}
+
+func (r *CachedConfluentSchemaRegistry) FetchSchemaContext(ctx context.Context, schemaID uint32) (*Schema, error) {
 	schema := r.Cache.LookupSchemaByID(schemaID)
 	if schema != nil {
+		emit(r.Hooks, Event{Type: EventCacheHit, SchemaID: schemaID})
 		return schema, nil
 	}
+	emit(r.Hooks, Event{Type: EventCacheMiss, SchemaID: schemaID})
 
-	schema, err := r.Upstream.FetchSchema(schemaID)
+	v, err := r.fetches.do(ctx, fmt.Sprintf("id:%d", schemaID), func() (interface{}, error) {
+		schema, err := fetchSchemaContext(ctx, r.Upstream, schemaID)
+		if err != nil {
+			return nil, err
+		}
+		return r.Cache.StoreSchemaByID(schemaID, schema), nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return r.Cache.StoreSchemaByID(schemaID, schema), nil
+	return v.(*Schema), nil
 }
 
 func (r *CachedConfluentSchemaRegistry) Register(subject string, schema *Schema) (uint32, error) {
+	return r.RegisterContext(context.Background(), subject, schema)
+}
+
+func (r *CachedConfluentSchemaRegistry) RegisterContext(ctx context.Context, subject string, schema *Schema) (uint32, error) {
 	schemaId := r.Cache.LookupIdBySchema(subject, schema)
 	if schemaId != 0 {
+		emit(r.Hooks, Event{Type: EventCacheHit, SchemaID: schemaId, Subject: subject})
 		return schemaId, nil
 	}
-	schemaId, err := r.Upstream.Register(subject, schema)
+	emit(r.Hooks, Event{Type: EventCacheMiss, Subject: subject})
+	v, err := r.fetches.do(ctx, "register:"+subject+schema.String(), func() (interface{}, error) {
+		schemaId, err := registerContext(ctx, r.Upstream, subject, schema)
+		if err != nil {
+			return nil, err
+		}
+		return r.Cache.StoreIdBySchema(subject, schema, schemaId), nil
+	})
 	if err != nil {
 		return 0, err
 	}
-	return r.Cache.StoreIdBySchema(subject, schema, schemaId), nil
+	return v.(uint32), nil
+}
+
+func (r *CachedConfluentSchemaRegistry) FetchSchemaBySubjectVersion(subject string, version int) (*RegisteredSchema, error) {
+	return r.FetchSchemaBySubjectVersionContext(context.Background(), subject, version)
+}
+
+func (r *CachedConfluentSchemaRegistry) FetchSchemaBySubjectVersionContext(ctx context.Context, subject string, version int) (*RegisteredSchema, error) {
+	if version != LatestVersion || r.cachesLatestVersion() {
+		schema := r.Cache.LookupSchemaBySubjectVersion(subject, version)
+		if schema != nil {
+			schemaID := r.Cache.LookupIdBySchema(subject, schema)
+			cachedVersion := version
+			if version == LatestVersion {
+				cachedVersion = r.Cache.LookupVersionBySchema(subject, schema)
+			}
+			if schemaID != 0 && cachedVersion != 0 {
+				emit(r.Hooks, Event{Type: EventCacheHit, SchemaID: schemaID, Subject: subject, Version: cachedVersion})
+				return &RegisteredSchema{Subject: subject, Version: cachedVersion, ID: schemaID, Schema: schema}, nil
+			}
+		}
+	}
+	emit(r.Hooks, Event{Type: EventCacheMiss, Subject: subject, Version: version})
+
+	upstream, err := r.subjectUpstream()
+	if err != nil {
+		return nil, err
+	}
+	v, err := r.fetches.do(ctx, "version:"+subjectVersionKey(subject, version), func() (interface{}, error) {
+		registered, err := upstream.FetchSchemaBySubjectVersionContext(ctx, subject, version)
+		if err != nil {
+			return nil, err
+		}
+		r.storeRegisteredSchema(subject, registered.Schema, registered)
+		if version == LatestVersion && r.cachesLatestVersion() {
+			r.Cache.StoreSchemaBySubjectVersion(subject, LatestVersion, registered.Schema)
+		}
+		return registered, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*RegisteredSchema), nil
+}
+
+func (r *CachedConfluentSchemaRegistry) LookupSchema(subject string, schema *Schema) (*RegisteredSchema, error) {
+	return r.LookupSchemaContext(context.Background(), subject, schema)
+}
+
+func (r *CachedConfluentSchemaRegistry) LookupSchemaContext(ctx context.Context, subject string, schema *Schema) (*RegisteredSchema, error) {
+	schemaID := r.Cache.LookupIdBySchema(subject, schema)
+	version := r.Cache.LookupVersionBySchema(subject, schema)
+	if schemaID != 0 && version != 0 {
+		emit(r.Hooks, Event{Type: EventCacheHit, SchemaID: schemaID, Subject: subject, Version: version})
+		return &RegisteredSchema{Subject: subject, Version: version, ID: schemaID, Schema: schema}, nil
+	}
+	emit(r.Hooks, Event{Type: EventCacheMiss, Subject: subject})
+
+	upstream, err := r.subjectUpstream()
+	if err != nil {
+		return nil, err
+	}
+	v, err := r.fetches.do(ctx, "lookup:"+subject+schema.String(), func() (interface{}, error) {
+		registered, err := upstream.LookupSchemaContext(ctx, subject, schema)
+		if err != nil {
+			return nil, err
+		}
+		r.storeRegisteredSchema(subject, schema, registered)
+		return registered, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*RegisteredSchema), nil
+}
+
+func (r *CachedConfluentSchemaRegistry) storeRegisteredSchema(subject string, schema *Schema, registered *RegisteredSchema) {
+	r.Cache.StoreSchemaBySubjectVersion(subject, registered.Version, registered.Schema)
+	r.Cache.StoreIdBySchema(subject, schema, registered.ID)
+	r.Cache.StoreVersionBySchema(subject, schema, registered.Version)
+}
+
+func (r *CachedConfluentSchemaRegistry) cachesLatestVersion() bool {
+	c, ok := r.Cache.(LatestVersionCache)
+	return ok && c.CachesLatestVersion()
+}
+
+func (r *CachedConfluentSchemaRegistry) subjectUpstream() (SubjectRegistry, error) {
+	upstream, ok := r.Upstream.(SubjectRegistry)
+	if !ok {
+		return nil, fmt.Errorf("upstream registry does not support subject lookups: %T", r.Upstream)
+	}
+	return upstream, nil
 }
@@ -0,0 +1,198 @@
+package avroturf_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/wanabe/avroturf-go"
+	"github.com/wanabe/avroturf-go/mock_avroturf"
+)
+
+func TestCachedLookupSchema(t *testing.T) {
+	calls := 0
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			calls++
+			if expected := "http://schema-registry:8081/subjects/TestRecord"; req.URL.String() != expected {
+				t.Errorf("expected '%s' but got '%s'", expected, req.URL)
+			}
+			body := `{"subject":"TestRecord","version":2,"id":135,"schema":"\"string\""}`
+			return &http.Response{
+				Body: &stubReadCloser{
+					body: []byte(body),
+				},
+			}, nil
+		},
+	}
+	r := &avroturf.CachedConfluentSchemaRegistry{
+		Upstream: &avroturf.ConfluentSchemaRegistry{RegistryURL: "http://schema-registry:8081", HTTPClient: client},
+		Cache:    avroturf.NewInMemoryCache(),
+	}
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		s, err := r.LookupSchema("TestRecord", schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.ID != 135 || s.Version != 2 {
+			t.Errorf("unexpected result: %+v", s)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call but got %d", calls)
+	}
+
+	s, err := r.FetchSchemaBySubjectVersion("TestRecord", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID != 135 || s.Schema.String() != `"string"` {
+		t.Errorf("unexpected result: %+v", s)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call but got %d", calls)
+	}
+}
+
+func TestCachedFetchSchemaBySubjectVersion(t *testing.T) {
+	calls := 0
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			calls++
+			body := `{"subject":"TestRecord","version":2,"id":135,"schema":"\"string\""}`
+			return &http.Response{
+				Body: &stubReadCloser{
+					body: []byte(body),
+				},
+			}, nil
+		},
+	}
+	r := &avroturf.CachedConfluentSchemaRegistry{
+		Upstream: &avroturf.ConfluentSchemaRegistry{RegistryURL: "http://schema-registry:8081", HTTPClient: client},
+		Cache:    avroturf.NewInMemoryCache(),
+	}
+	for i := 0; i < 2; i++ {
+		_, err := r.FetchSchemaBySubjectVersion("TestRecord", avroturf.LatestVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls for latest version but got %d", calls)
+	}
+	for i := 0; i < 2; i++ {
+		_, err := r.FetchSchemaBySubjectVersion("TestRecord", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls but got %d", calls)
+	}
+
+	r.Cache = avroturf.NewBoundedInMemoryCache(0, time.Minute)
+	for i := 0; i < 2; i++ {
+		s, err := r.FetchSchemaBySubjectVersion("TestRecord", avroturf.LatestVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Version != 2 || s.ID != 135 {
+			t.Errorf("unexpected result: %+v", s)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("expected latest version to be cached within ttl but got %d calls", calls)
+	}
+}
+
+func TestNewCachedSchemaRegistry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "avroturf-disk-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	upstream.EXPECT().FetchSchemaContext(gomock.Any(), uint32(135)).Return(schema, nil)
+	upstream.EXPECT().RegisterContext(gomock.Any(), "subject1", schema).Return(uint32(135), nil)
+
+	memory := avroturf.NewInMemoryCache()
+	disk := avroturf.NewDiskCache(dir)
+	registry := avroturf.NewCachedSchemaRegistry(upstream, memory, disk)
+	for i := 0; i < 2; i++ {
+		s, err := registry.FetchSchema(135)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.String() != schema.String() {
+			t.Errorf("expected %v but got %v", schema, s)
+		}
+		id, err := registry.Register("subject1", schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 135 {
+			t.Errorf("expected 135 but got %d", id)
+		}
+	}
+	if memory.LookupSchemaByID(135) == nil || disk.LookupSchemaByID(135) == nil {
+		t.Error("expected schema to be stored in all caches")
+	}
+
+	registry = avroturf.NewCachedSchemaRegistry(upstream, avroturf.NewInMemoryCache(), disk)
+	s, err := registry.FetchSchema(135)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.String() != schema.String() {
+		t.Errorf("expected %v but got %v", schema, s)
+	}
+
+	_, err = registry.(avroturf.SubjectRegistry).LookupSchema("subject1", schema)
+	if err == nil || err.Error() != "upstream registry does not support subject lookups: *mock_avroturf.MockContextSchemaRegistry" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCachedFetchSchemaDeduplicatesFetches(t *testing.T) {
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := &slowSchemaRegistry{delay: 20 * time.Millisecond, schema: schema}
+	r := &avroturf.CachedConfluentSchemaRegistry{Upstream: upstream, Cache: avroturf.NewInMemoryCache()}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := r.FetchSchema(2); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+			if _, err := r.Register("subject1", schema); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if upstream.calls != 2 {
+		t.Errorf("expected 2 upstream calls but got %d", upstream.calls)
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/wanabe/avroturf-go"
+	"github.com/wanabe/avroturf-go/avroturfgen"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"decode":       {"decode [-hex] [FILE]", runDecode},
+	"encode":       {"encode -subject SUBJECT -name NAME [-hex] [-lookup] [FILE]", runEncode},
+	"register":     {"register [-subject SUBJECT] -name NAME", runRegister},
+	"lookup":       {"lookup -subject SUBJECT -name NAME", runLookup},
+	"check-compat": {"check-compat -subject SUBJECT -name NAME [-version VERSION]", runCheckCompat},
+	"subjects":     {"subjects", runSubjects},
+	"schema":       {"schema ID", runSchema},
+	"export":       {"export -dir DIR", runExport},
+	"import":       {"import -dir DIR", runImport},
+	"generate":     {"generate [-package NAME] [-out FILE]", runGenerate},
+}
+
+var commandNames = []string{"decode", "encode", "register", "lookup", "check-compat", "subjects", "schema", "export", "import", "generate"}
+
+var errIncompatible = errors.New("schema is incompatible")
+
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	registryURL string
+	schemaPath  string
+	namespace   string
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		c.usage()
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "avroturf: unknown command %q\n", args[0])
+		c.usage()
+		return 2
+	}
+	if err := cmd.run(context.Background(), c, args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 2
+		}
+		fmt.Fprintf(stderr, "avroturf %s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "usage: avroturf <command> [flags]")
+	fmt.Fprintln(c.stderr)
+	for _, name := range commandNames {
+		fmt.Fprintf(c.stderr, "  avroturf %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "common flags: -registry URL (env AVROTURF_REGISTRY_URL), -schemas PATH (env AVROTURF_SCHEMA_PATH), -namespace NAMESPACE (env AVROTURF_NAMESPACE)")
+}
+
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("avroturf "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.StringVar(&c.registryURL, "registry", os.Getenv("AVROTURF_REGISTRY_URL"), "schema registry URL (env AVROTURF_REGISTRY_URL)")
+	fs.StringVar(&c.schemaPath, "schemas", envOr("AVROTURF_SCHEMA_PATH", "."), "path to local .avsc schemas (env AVROTURF_SCHEMA_PATH)")
+	fs.StringVar(&c.namespace, "namespace", os.Getenv("AVROTURF_NAMESPACE"), "default namespace for schema names (env AVROTURF_NAMESPACE)")
+	return fs
+}
+
+func (c *cli) parse(fs *flag.FlagSet, args []string, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > maxArgs {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args()[maxArgs:])
+	}
+	return nil
+}
+
+func (c *cli) registry() (*avroturf.ConfluentSchemaRegistry, error) {
+	if c.registryURL == "" {
+		return nil, errors.New("registry URL is required (-registry or AVROTURF_REGISTRY_URL)")
+	}
+	return avroturf.NewConfluentSchemaRegistry(c.registryURL)
+}
+
+func (c *cli) messaging() (*avroturf.Messaging, error) {
+	if c.registryURL == "" {
+		return nil, errors.New("registry URL is required (-registry or AVROTURF_REGISTRY_URL)")
+	}
+	return avroturf.NewMessagingWithConfluent(c.namespace, c.schemaPath, c.registryURL)
+}
+
+func (c *cli) localSchema(name string) (*avroturf.Schema, error) {
+	if name == "" {
+		return nil, errors.New("-name is required")
+	}
+	return avroturf.NewSchemaStore(c.schemaPath).Find(name, c.namespace)
+}
+
+func (c *cli) readInput(fs *flag.FlagSet) ([]byte, error) {
+	if fs.NArg() == 0 || fs.Arg(0) == "-" {
+		return ioutil.ReadAll(c.stdin)
+	}
+	return ioutil.ReadFile(fs.Arg(0))
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.stdout, "%s\n", b)
+	return err
+}
+
+func envOr(key string, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func runDecode(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("decode")
+	hexInput := fs.Bool("hex", false, "read the message as hex text instead of raw bytes")
+	if err := c.parse(fs, args, 1); err != nil {
+		return err
+	}
+	m, err := c.messaging()
+	if err != nil {
+		return err
+	}
+	data, err := c.readInput(fs)
+	if err != nil {
+		return err
+	}
+	if *hexInput {
+		data, err = hex.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+		if err != nil {
+			return err
+		}
+	}
+	b, err := m.DecodeToJSONContext(ctx, data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.stdout, "%s\n", b)
+	return err
+}
+
+func runEncode(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("encode")
+	subject := fs.String("subject", "", "subject to register the schema under")
+	name := fs.String("name", "", "name of the local schema to encode with")
+	hexOutput := fs.Bool("hex", false, "write the message as hex text instead of raw bytes")
+	lookup := fs.Bool("lookup", false, "look up the schema id instead of registering the schema")
+	if err := c.parse(fs, args, 1); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+	m, err := c.messaging()
+	if err != nil {
+		return err
+	}
+	if *lookup {
+		m.EncodeMode = avroturf.EncodeModeLookup
+	}
+	jsonBytes, err := c.readInput(fs)
+	if err != nil {
+		return err
+	}
+	data, err := m.EncodeJSONContext(ctx, bytes.TrimSpace(jsonBytes), *subject, *name, c.namespace)
+	if err != nil {
+		return err
+	}
+	if *hexOutput {
+		_, err = fmt.Fprintln(c.stdout, hex.EncodeToString(data))
+		return err
+	}
+	_, err = c.stdout.Write(data)
+	return err
+}
+
+func runRegister(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("register")
+	subject := fs.String("subject", "", "subject to register under (defaults to the schema full name)")
+	name := fs.String("name", "", "name of the local schema to register")
+	if err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+	m, err := c.messaging()
+	if err != nil {
+		return err
+	}
+	schemaID, _, err := m.RegisterSchemaContext(ctx, *subject, *name, c.namespace)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.stdout, schemaID)
+	return err
+}
+
+func runLookup(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("lookup")
+	subject := fs.String("subject", "", "subject to look the schema up in")
+	name := fs.String("name", "", "name of the local schema to look up")
+	if err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *subject == "" {
+		return errors.New("-subject is required")
+	}
+	schema, err := c.localSchema(*name)
+	if err != nil {
+		return err
+	}
+	r, err := c.registry()
+	if err != nil {
+		return err
+	}
+	registered, err := r.LookupSchemaContext(ctx, *subject, schema)
+	if err != nil {
+		return err
+	}
+	return c.printJSON(map[string]interface{}{"subject": registered.Subject, "version": registered.Version, "id": registered.ID})
+}
+
+func runCheckCompat(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("check-compat")
+	subject := fs.String("subject", "", "subject to check against")
+	name := fs.String("name", "", "name of the local schema to check")
+	version := fs.Int("version", avroturf.LatestVersion, "version to check against (defaults to the latest)")
+	if err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *subject == "" {
+		return errors.New("-subject is required")
+	}
+	schema, err := c.localSchema(*name)
+	if err != nil {
+		return err
+	}
+	r, err := c.registry()
+	if err != nil {
+		return err
+	}
+	ok, messages, err := r.CheckCompatibilityContext(ctx, *subject, *version, schema)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		for _, message := range messages {
+			fmt.Fprintln(c.stdout, message)
+		}
+		return errIncompatible
+	}
+	_, err = fmt.Fprintln(c.stdout, "compatible")
+	return err
+}
+
+func runSubjects(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("subjects")
+	if err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	r, err := c.registry()
+	if err != nil {
+		return err
+	}
+	subjects, err := r.ListSubjectsContext(ctx)
+	if err != nil {
+		return err
+	}
+	for _, subject := range subjects {
+		fmt.Fprintln(c.stdout, subject)
+	}
+	return nil
+}
+
+func runSchema(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("schema")
+	if err := c.parse(fs, args, 1); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("schema id is required")
+	}
+	schemaID, err := strconv.ParseUint(fs.Arg(0), 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid schema id %q", fs.Arg(0))
+	}
+	r, err := c.registry()
+	if err != nil {
+		return err
+	}
+	schema, err := r.FetchSchemaContext(ctx, uint32(schemaID))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(c.stdout, schema.String())
+	return err
+}
+
+func runExport(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("export")
+	dir := fs.String("dir", ".", "directory to write the snapshot to")
+	if err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	r, err := c.registry()
+	if err != nil {
+		return err
+	}
+	manifest, err := avroturf.ExportManifest(ctx, r, *dir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "exported %d schemas and %d subjects to %s\n", len(manifest.Schemas), len(manifest.Subjects), *dir)
+	return nil
+}
+
+func runImport(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("import")
+	dir := fs.String("dir", ".", "directory to read the snapshot from")
+	if err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	r, err := c.registry()
+	if err != nil {
+		return err
+	}
+	if err := avroturf.ImportManifest(ctx, r, *dir); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "imported %s into %s\n", *dir, c.registryURL)
+	return nil
+}
+
+func runGenerate(ctx context.Context, c *cli, args []string) error {
+	fs := c.flagSet("generate")
+	pkg := fs.String("package", os.Getenv("GOPACKAGE"), "package name of the generated file (env GOPACKAGE)")
+	out := fs.String("out", "", "file to write the generated code to (defaults to stdout)")
+	if err := c.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *pkg == "" {
+		return errors.New("-package is required")
+	}
+	src, err := avroturfgen.Generate(avroturf.NewSchemaStore(c.schemaPath), *pkg)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = c.stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(*out, src, 0644)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+	"github.com/wanabe/avroturf-go/avroturftest"
+)
+
+func mustParse(t *testing.T, s string) *avroturf.Schema {
+	t.Helper()
+	schema, err := avroturf.Parse(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestExportImport(t *testing.T) {
+	source := avroturftest.NewRegistry()
+	defer source.Close()
+	target := avroturftest.NewRegistry()
+	defer target.Close()
+
+	schema := mustParse(t, `{"type":"record","name":"TestSchemaRoot","fields":[{"name":"str","type":"string"}]}`)
+	if _, err := source.SchemaRegistry().Register("other-value", mustParse(t, `"string"`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := source.SchemaRegistry().Register("test-value", schema); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "avroturf-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"export", "-registry", source.URL, "-dir", dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("export exited with %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "exported 2 schemas and 2 subjects") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+
+	os.Setenv("AVROTURF_REGISTRY_URL", target.URL)
+	defer os.Unsetenv("AVROTURF_REGISTRY_URL")
+	stdout.Reset()
+	if code := run([]string{"import", "-dir", dir}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("import exited with %d: %s", code, stderr.String())
+	}
+	registered, err := target.SchemaRegistry().LookupSchema("test-value", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registered.ID != 2 || registered.Version != 1 {
+		t.Errorf("unexpected registered schema: %+v", registered)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	srv := avroturftest.NewRegistry()
+	defer srv.Close()
+	common := []string{"-registry", srv.URL, "-schemas", "../../testdata", "-namespace", "test-namespace"}
+
+	var stdout, stderr bytes.Buffer
+	args := append([]string{"encode", "-subject", "test-value", "-name", "test-name", "-hex"}, common...)
+	if code := run(args, strings.NewReader(`{"str":"hoge"}`), &stdout, &stderr); code != 0 {
+		t.Fatalf("encode exited with %d: %s", code, stderr.String())
+	}
+	if expected := "000000000108686f6765\n"; stdout.String() != expected {
+		t.Errorf("expected %q but got %q", expected, stdout.String())
+	}
+	encoded := stdout.String()
+
+	stdout.Reset()
+	if code := run(append([]string{"decode", "-hex"}, common...), strings.NewReader(encoded), &stdout, &stderr); code != 0 {
+		t.Fatalf("decode exited with %d: %s", code, stderr.String())
+	}
+	if expected := `{"str":"hoge"}` + "\n"; stdout.String() != expected {
+		t.Errorf("expected %q but got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	args = append([]string{"encode", "-subject", "test-value", "-name", "test-name"}, common...)
+	if code := run(args, strings.NewReader(`{"str":"hoge"}`), &stdout, &stderr); code != 0 {
+		t.Fatalf("encode exited with %d: %s", code, stderr.String())
+	}
+	raw := stdout.String()
+	stdout.Reset()
+	if code := run(append([]string{"decode"}, common...), strings.NewReader(raw), &stdout, &stderr); code != 0 {
+		t.Fatalf("decode exited with %d: %s", code, stderr.String())
+	}
+	if expected := `{"str":"hoge"}` + "\n"; stdout.String() != expected {
+		t.Errorf("expected %q but got %q", expected, stdout.String())
+	}
+
+	args = append([]string{"encode", "-subject", "missing-value", "-name", "test-name", "-lookup"}, common...)
+	if code := run(args, strings.NewReader(`{"str":"hoge"}`), &stdout, &stderr); code != 1 {
+		t.Errorf("expected lookup of unregistered subject to fail but got %d", code)
+	}
+}
+
+func TestRegistryCommands(t *testing.T) {
+	srv := avroturftest.NewRegistry()
+	defer srv.Close()
+	common := []string{"-registry", srv.URL, "-schemas", "../../testdata", "-namespace", "test-namespace"}
+
+	var stdout, stderr bytes.Buffer
+	for _, tc := range []struct {
+		args     []string
+		code     int
+		expected string
+	}{
+		{[]string{"register", "-subject", "test-value", "-name", "test-name"}, 0, "1\n"},
+		{[]string{"register", "-subject", "test-value", "-name", "test-reader"}, 0, "2\n"},
+		{[]string{"register", "-subject", "other-value", "-name", "test-name"}, 0, "1\n"},
+		{[]string{"lookup", "-subject", "test-value", "-name", "test-name"}, 0, `{"id":1,"subject":"test-value","version":1}` + "\n"},
+		{[]string{"check-compat", "-subject", "test-value", "-name", "test-name"}, 0, "compatible\n"},
+		{[]string{"subjects"}, 0, "other-value\ntest-value\n"},
+		{[]string{"schema", "1"}, 0, `{"fields":[{"name":"str","type":"string"}],"name":"TestSchemaRoot","type":"record"}` + "\n"},
+	} {
+		stdout.Reset()
+		stderr.Reset()
+		args := append(append([]string{tc.args[0]}, common...), tc.args[1:]...)
+		if code := run(args, nil, &stdout, &stderr); code != tc.code {
+			t.Errorf("%v: expected exit code %d but got %d: %s", tc.args, tc.code, code, stderr.String())
+		}
+		if stdout.String() != tc.expected {
+			t.Errorf("%v: expected %q but got %q", tc.args, tc.expected, stdout.String())
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "avroturf-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	incompatible := `{"type":"record","name":"TestSchemaRoot","fields":[{"name":"str","type":"long"}]}`
+	if err := ioutil.WriteFile(filepath.Join(dir, "incompatible.avsc"), []byte(incompatible), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	stderr.Reset()
+	args := []string{"check-compat", "-registry", srv.URL, "-schemas", dir, "-subject", "test-value", "-name", "incompatible"}
+	if code := run(args, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 but got %d", code)
+	}
+	if stdout.Len() == 0 || !strings.Contains(stderr.String(), "schema is incompatible") {
+		t.Errorf("unexpected output: %q %q", stdout.String(), stderr.String())
+	}
+
+	stderr.Reset()
+	if code := run(append([]string{"schema"}, common...), nil, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "schema id is required") {
+		t.Errorf("unexpected result: %d %s", code, stderr.String())
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avroturf-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stdout, stderr bytes.Buffer
+	out := filepath.Join(dir, "example_avro.go")
+	if code := run([]string{"generate", "-schemas", "../../avroturfgen/testdata", "-package", "example", "-out", out}, nil, &stdout, &stderr); code != 0 {
+		t.Fatalf("generate exited with %d: %s", code, stderr.String())
+	}
+	generated, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := ioutil.ReadFile("../../avroturfgen/internal/example/example_avro.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(generated) != string(expected) {
+		t.Errorf("unexpected generated code:\n%s", generated)
+	}
+
+	os.Unsetenv("GOPACKAGE")
+	if code := run([]string{"generate", "-schemas", "../../avroturfgen/testdata"}, nil, &stdout, &stderr); code != 1 || !strings.Contains(stderr.String(), "-package is required") {
+		t.Errorf("unexpected result: %d %s", code, stderr.String())
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run(nil, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 but got %d", code)
+	}
+	if code := run([]string{"unknown"}, nil, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit code 2 but got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown command "unknown"`) {
+		t.Errorf("unexpected output: %s", stderr.String())
+	}
+	stderr.Reset()
+	os.Unsetenv("AVROTURF_REGISTRY_URL")
+	if code := run([]string{"export"}, nil, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1 but got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "registry URL is required") {
+		t.Errorf("unexpected output: %s", stderr.String())
+	}
+}
@@ -0,0 +1,155 @@
+package avroturf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hamba/avro"
+)
+
+type Incompatibility struct {
+	Path    string
+	Message string
+}
+
+func (i Incompatibility) String() string {
+	return i.Path + ": " + i.Message
+}
+
+func CheckCompatibility(reader *Schema, writer *Schema) []Incompatibility {
+	c := &compatibilityChecker{seen: map[string]bool{}}
+	c.check(reader.Schema, writer.Schema, nil)
+	return c.incompatibilities
+}
+
+func CheckCompatibilityLevel(level CompatibilityLevel, newSchema *Schema, previous []*Schema) []Incompatibility {
+	if len(previous) == 0 {
+		return nil
+	}
+	targets := previous
+	switch level {
+	case CompatibilityBackward, CompatibilityForward, CompatibilityFull:
+		targets = previous[len(previous)-1:]
+	case CompatibilityNone:
+		return nil
+	}
+	var incompatibilities []Incompatibility
+	for _, old := range targets {
+		switch level {
+		case CompatibilityBackward, CompatibilityBackwardTransitive:
+			incompatibilities = append(incompatibilities, CheckCompatibility(newSchema, old)...)
+		case CompatibilityForward, CompatibilityForwardTransitive:
+			incompatibilities = append(incompatibilities, CheckCompatibility(old, newSchema)...)
+		default:
+			incompatibilities = append(incompatibilities, CheckCompatibility(newSchema, old)...)
+			incompatibilities = append(incompatibilities, CheckCompatibility(old, newSchema)...)
+		}
+	}
+	return incompatibilities
+}
+
+type compatibilityChecker struct {
+	seen              map[string]bool
+	incompatibilities []Incompatibility
+}
+
+func (c *compatibilityChecker) report(path []string, format string, args ...interface{}) {
+	c.incompatibilities = append(c.incompatibilities, Incompatibility{
+		Path:    strings.Join(path, "."),
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *compatibilityChecker) check(reader avro.Schema, writer avro.Schema, path []string) {
+	reader = derefSchema(reader)
+	writer = derefSchema(writer)
+
+	if w, ok := writer.(*avro.UnionSchema); ok {
+		for _, branch := range w.Types() {
+			c.check(reader, branch, path)
+		}
+		return
+	}
+	if r, ok := reader.(*avro.UnionSchema); ok {
+		if matchUnionBranch(writer, r) == nil {
+			c.report(path, "reader union lacks type %s", unionBranchName(writer))
+			return
+		}
+		c.check(matchUnionBranch(writer, r), writer, path)
+		return
+	}
+	if reader.Type() != writer.Type() {
+		if !isPromotable(writer.Type(), reader.Type()) {
+			c.report(path, "type changed from %s to %s", writer.Type(), reader.Type())
+		}
+		return
+	}
+
+	switch r := reader.(type) {
+	case *avro.RecordSchema:
+		w := writer.(*avro.RecordSchema)
+		path = append(path, "record", r.Name())
+		if !c.checkName(r, w, path) {
+			return
+		}
+		key := r.FullName() + "\x00" + r.String() + "\x00" + w.String()
+		if c.seen[key] {
+			return
+		}
+		c.seen[key] = true
+		writerFields := map[string]*avro.Field{}
+		for _, field := range w.Fields() {
+			writerFields[field.Name()] = field
+		}
+		for _, field := range r.Fields() {
+			fieldPath := append(append([]string{}, path...), "field", field.Name())
+			wf, hit := writerFields[field.Name()]
+			if !hit {
+				if !field.HasDefault() {
+					c.report(fieldPath, "field added without default")
+				}
+				continue
+			}
+			c.check(field.Type(), wf.Type(), fieldPath)
+		}
+	case *avro.EnumSchema:
+		w := writer.(*avro.EnumSchema)
+		path = append(path, "enum", r.Name())
+		if !c.checkName(r, w, path) {
+			return
+		}
+		if _, ok := r.Prop("default").(string); ok {
+			return
+		}
+		symbols := map[string]bool{}
+		for _, symbol := range r.Symbols() {
+			symbols[symbol] = true
+		}
+		for _, symbol := range w.Symbols() {
+			if !symbols[symbol] {
+				c.report(path, "reader lacks symbol %s", symbol)
+			}
+		}
+	case *avro.FixedSchema:
+		w := writer.(*avro.FixedSchema)
+		path = append(path, "fixed", r.Name())
+		if !c.checkName(r, w, path) {
+			return
+		}
+		if r.Size() != w.Size() {
+			c.report(path, "size changed from %d to %d", w.Size(), r.Size())
+		}
+	case *avro.ArraySchema:
+		c.check(r.Items(), writer.(*avro.ArraySchema).Items(), append(path, "items"))
+	case *avro.MapSchema:
+		c.check(r.Values(), writer.(*avro.MapSchema).Values(), append(path, "values"))
+	}
+}
+
+func (c *compatibilityChecker) checkName(reader avro.NamedSchema, writer avro.NamedSchema, path []string) bool {
+	if reader.FullName() != writer.FullName() {
+		c.report(path, "name changed from %s to %s", writer.FullName(), reader.FullName())
+		return false
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package avroturf_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func TestCheckCompatibilityLocally(t *testing.T) {
+	writer := mustParse(t, `
+		{
+			"type": "record",
+			"name": "Foo",
+			"fields": [
+				{"name": "bar", "type": "int"},
+				{"name": "num", "type": "int"},
+				{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN"]}},
+				{"name": "opt", "type": ["null", "string"]}
+			]
+		}
+	`)
+	reader := mustParse(t, `
+		{
+			"type": "record",
+			"name": "Foo",
+			"fields": [
+				{"name": "bar", "type": "string"},
+				{"name": "num", "type": "long"},
+				{"name": "added", "type": "string"},
+				{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED"]}},
+				{"name": "opt", "type": ["null", "int"]}
+			]
+		}
+	`)
+
+	expected := []avroturf.Incompatibility{
+		{Path: "record.Foo.field.bar", Message: "type changed from int to string"},
+		{Path: "record.Foo.field.added", Message: "field added without default"},
+		{Path: "record.Foo.field.color.enum.Color", Message: "reader lacks symbol GREEN"},
+		{Path: "record.Foo.field.opt", Message: "reader union lacks type string"},
+	}
+	actual := avroturf.CheckCompatibility(reader, writer)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v but got %v", expected, actual)
+	}
+	if actual[0].String() != "record.Foo.field.bar: type changed from int to string" {
+		t.Errorf("unexpected string: %s", actual[0])
+	}
+
+	if actual := avroturf.CheckCompatibility(writer, writer); actual != nil {
+		t.Errorf("expected nil but got %v", actual)
+	}
+}
+
+func TestCheckCompatibilityLevel(t *testing.T) {
+	v1 := mustParse(t, `{"type": "record", "name": "Foo", "fields": [{"name": "a", "type": "string"}]}`)
+	v2 := mustParse(t, `{"type": "record", "name": "Foo", "fields": [{"name": "a", "type": "string"}, {"name": "b", "type": "string", "default": ""}]}`)
+	v3 := mustParse(t, `{"type": "record", "name": "Foo", "fields": [{"name": "b", "type": "string", "default": ""}]}`)
+
+	tests := []struct {
+		level        avroturf.CompatibilityLevel
+		compatible   bool
+		newSchema    *avroturf.Schema
+		previousList []*avroturf.Schema
+	}{
+		{avroturf.CompatibilityBackward, true, v2, []*avroturf.Schema{v1}},
+		{avroturf.CompatibilityForward, true, v2, []*avroturf.Schema{v1}},
+		{avroturf.CompatibilityFull, true, v2, []*avroturf.Schema{v1}},
+		{avroturf.CompatibilityBackward, true, v3, []*avroturf.Schema{v1, v2}},
+		{avroturf.CompatibilityForward, false, v3, []*avroturf.Schema{v1, v2}},
+		{avroturf.CompatibilityBackwardTransitive, true, v3, []*avroturf.Schema{v1, v2}},
+		{avroturf.CompatibilityFullTransitive, false, v3, []*avroturf.Schema{v1, v2}},
+		{avroturf.CompatibilityNone, true, v3, []*avroturf.Schema{v1, v2}},
+	}
+	for _, test := range tests {
+		actual := avroturf.CheckCompatibilityLevel(test.level, test.newSchema, test.previousList)
+		if (len(actual) == 0) != test.compatible {
+			t.Errorf("%s: expected compatible=%v but got %v", test.level, test.compatible, actual)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package avroturf
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -11,31 +13,92 @@ import (
 	"net/url"
 	"path"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type ConfluentSchemaRegistry struct {
-	RegistryURL string
+	RegistryURL  string
+	RegistryURLs []string
+	Retry        RetryPolicy
+
+	BasicAuthUserInfo string
+	TokenSource       TokenSource
+	Headers           http.Header
+
+	HTTPClient HTTPDoer
+	Logger     *log.Logger
+	Hooks      Hooks
+
+	current uint32
+}
+
+type RegisteredSchema struct {
+	Subject string
+	Version int
+	ID      uint32
+	Schema  *Schema
+}
+
+type registeredSchemaResponse struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+	ID      uint32 `json:"id"`
+	Schema  string `json:"schema"`
 }
 
-type httpClient interface {
+func (res *registeredSchemaResponse) registeredSchema() (*RegisteredSchema, error) {
+	schema, err := Parse(res.Schema)
+	if err != nil {
+		return nil, err
+	}
+	return &RegisteredSchema{Subject: res.Subject, Version: res.Version, ID: res.ID, Schema: schema}, nil
+}
+
+type HTTPDoer interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
 const maxUint32 = int(^uint32(0))
 
+const LatestVersion = -1
+
 var Logger *log.Logger
-var HTTPClient httpClient
+var HTTPClient HTTPDoer
 
 func init() {
 	HTTPClient = &http.Client{}
 }
 
-func (r *ConfluentSchemaRegistry) FetchSchema(schemaID uint32) (*Schema, error) {
-	if Logger != nil {
-		Logger.Printf("Fetching schema with id %d\n", schemaID)
+func NewConfluentSchemaRegistry(registryURL string, opts ...RegistryOption) (*ConfluentSchemaRegistry, error) {
+	r := &ConfluentSchemaRegistry{RegistryURL: registryURL}
+	c := &registryConfig{}
+	for _, opt := range opts {
+		opt(r, c)
+	}
+	if r.BasicAuthUserInfo != "" && r.TokenSource != nil {
+		return nil, errBasicAuthWithToken
 	}
-	data, err := r.request("GET", fmt.Sprintf("/schemas/ids/%d", schemaID), nil)
+	if r.HTTPClient != nil && (c.tlsConfig != nil || c.timeout > 0) {
+		return nil, errors.New("TLS config and timeout cannot be combined with a custom HTTP client; configure the client instead")
+	}
+	if c.tlsConfig != nil || c.timeout > 0 {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = c.tlsConfig
+		r.HTTPClient = &http.Client{Transport: transport, Timeout: c.timeout}
+	}
+	return r, nil
+}
+
+func (r *ConfluentSchemaRegistry) FetchSchema(schemaID uint32) (*Schema, error) {
+	return r.FetchSchemaContext(context.Background(), schemaID)
+}
+
+func (r *ConfluentSchemaRegistry) FetchSchemaContext(ctx context.Context, schemaID uint32) (*Schema, error) {
+	r.logf("Fetching schema with id %d\n", schemaID)
+	data, err := r.request(ctx, "GET", fmt.Sprintf("/schemas/ids/%d", schemaID), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -43,17 +106,24 @@ func (r *ConfluentSchemaRegistry) FetchSchema(schemaID uint32) (*Schema, error)
 	if !ok {
 		return nil, errors.New("unexpected schema-registry response")
 	}
-	return Parse(json)
+	schema, err := Parse(json)
+	if err != nil {
+		return nil, err
+	}
+	emit(r.Hooks, Event{Type: EventSchemaFetched, SchemaID: schemaID})
+	return schema, nil
 }
 
 func (r *ConfluentSchemaRegistry) Register(subject string, schema *Schema) (uint32, error) {
-	builder := &strings.Builder{}
-	err := json.NewEncoder(builder).Encode(map[string]string{"schema": schema.String()})
+	return r.RegisterContext(context.Background(), subject, schema)
+}
+
+func (r *ConfluentSchemaRegistry) RegisterContext(ctx context.Context, subject string, schema *Schema) (uint32, error) {
+	body, err := schemaBody(schema)
 	if err != nil {
 		return 0, err
 	}
-	body := ioutil.NopCloser(strings.NewReader(strings.TrimRight(builder.String(), "\n")))
-	data, err := r.request("POST", fmt.Sprintf("/subjects/%s/versions", subject), body)
+	data, err := r.request(ctx, "POST", subjectPath(subject, "versions"), body)
 	if err != nil {
 		return 0, err
 	}
@@ -68,29 +138,263 @@ func (r *ConfluentSchemaRegistry) Register(subject string, schema *Schema) (uint
 	}
 	schemaID := uint32(fid)
 
-	if Logger != nil {
-		Logger.Printf("Registered schema for subject `%s`; id = %d\n", subject, schemaID)
-	}
+	r.logf("Registered schema for subject `%s`; id = %d\n", subject, schemaID)
+	emit(r.Hooks, Event{Type: EventSchemaRegistered, SchemaID: schemaID, Subject: subject})
 	return schemaID, nil
 }
 
-func (r *ConfluentSchemaRegistry) request(method string, p string, body io.ReadCloser) (map[string]interface{}, error) {
-	result := make(map[string]interface{})
-	u, err := url.Parse(r.RegistryURL)
+func (r *ConfluentSchemaRegistry) ListSubjects() ([]string, error) {
+	return r.ListSubjectsContext(context.Background())
+}
+
+func (r *ConfluentSchemaRegistry) ListSubjectsContext(ctx context.Context) ([]string, error) {
+	subjects := []string{}
+	err := r.requestJSON(ctx, "GET", "/subjects", nil, nil, &subjects)
+	if err != nil {
+		return nil, err
+	}
+	return subjects, nil
+}
+
+func (r *ConfluentSchemaRegistry) ListVersions(subject string) ([]int, error) {
+	return r.ListVersionsContext(context.Background(), subject)
+}
+
+func (r *ConfluentSchemaRegistry) ListVersionsContext(ctx context.Context, subject string) ([]int, error) {
+	versions := []int{}
+	err := r.requestJSON(ctx, "GET", subjectPath(subject, "versions"), nil, nil, &versions)
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *ConfluentSchemaRegistry) FetchSchemaBySubjectVersion(subject string, version int) (*RegisteredSchema, error) {
+	return r.FetchSchemaBySubjectVersionContext(context.Background(), subject, version)
+}
+
+func (r *ConfluentSchemaRegistry) FetchSchemaBySubjectVersionContext(ctx context.Context, subject string, version int) (*RegisteredSchema, error) {
+	r.logf("Fetching schema for subject `%s` with version %s\n", subject, versionString(version))
+	res := &registeredSchemaResponse{}
+	err := r.requestJSON(ctx, "GET", subjectPath(subject, "versions", versionString(version)), nil, nil, res)
 	if err != nil {
 		return nil, err
 	}
-	u.Path = path.Join(u.Path, p)
-	h := map[string][]string{"Content-type": {"application/json"}}
-	req := &http.Request{Method: method, URL: u, Header: h, Body: body}
-	res, err := HTTPClient.Do(req)
+	return res.registeredSchema()
+}
+
+func (r *ConfluentSchemaRegistry) LookupSchema(subject string, schema *Schema) (*RegisteredSchema, error) {
+	return r.LookupSchemaContext(context.Background(), subject, schema)
+}
+
+func (r *ConfluentSchemaRegistry) LookupSchemaContext(ctx context.Context, subject string, schema *Schema) (*RegisteredSchema, error) {
+	body, err := schemaBody(schema)
 	if err != nil {
 		return nil, err
 	}
+	res := &registeredSchemaResponse{}
+	err = r.requestJSON(ctx, "POST", subjectPath(subject), nil, body, res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Schema == "" {
+		res.Schema = schema.String()
+	}
+	return res.registeredSchema()
+}
 
-	err = json.NewDecoder(res.Body).Decode(&result)
+func (r *ConfluentSchemaRegistry) DeleteSubject(subject string, permanent bool) ([]int, error) {
+	return r.DeleteSubjectContext(context.Background(), subject, permanent)
+}
+
+func (r *ConfluentSchemaRegistry) DeleteSubjectContext(ctx context.Context, subject string, permanent bool) ([]int, error) {
+	versions := []int{}
+	err := r.requestJSON(ctx, "DELETE", subjectPath(subject), permanentQuery(permanent), nil, &versions)
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (r *ConfluentSchemaRegistry) DeleteSchemaVersion(subject string, version int, permanent bool) (int, error) {
+	return r.DeleteSchemaVersionContext(context.Background(), subject, version, permanent)
+}
+
+func (r *ConfluentSchemaRegistry) DeleteSchemaVersionContext(ctx context.Context, subject string, version int, permanent bool) (int, error) {
+	var deleted int
+	err := r.requestJSON(ctx, "DELETE", subjectPath(subject, "versions", versionString(version)), permanentQuery(permanent), nil, &deleted)
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+func schemaBody(schema *Schema) (io.ReadCloser, error) {
+	builder := &strings.Builder{}
+	err := json.NewEncoder(builder).Encode(map[string]string{"schema": schema.String()})
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(strings.NewReader(strings.TrimRight(builder.String(), "\n"))), nil
+}
+
+func subjectPath(subject string, elem ...string) string {
+	return "/subjects/" + strings.Join(append([]string{pathSegment(subject)}, elem...), "/")
+}
+
+func pathSegment(s string) string {
+	switch s {
+	case ".":
+		return "%2E"
+	case "..":
+		return "%2E%2E"
+	}
+	return url.PathEscape(s)
+}
+
+func versionString(version int) string {
+	if version == LatestVersion {
+		return "latest"
+	}
+	return strconv.Itoa(version)
+}
+
+func endpointOf(p string) string {
+	segments := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i := 1; i < len(segments); i++ {
+		switch segments[i-1] {
+		case "subjects", "config":
+			segments[i] = "{subject}"
+		case "versions":
+			segments[i] = "{version}"
+		case "ids":
+			segments[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+func permanentQuery(permanent bool) url.Values {
+	if !permanent {
+		return nil
+	}
+	return url.Values{"permanent": {"true"}}
+}
+
+func (r *ConfluentSchemaRegistry) request(ctx context.Context, method string, p string, body io.ReadCloser) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	err := r.requestJSON(ctx, method, p, nil, body, &result)
 	if err != nil {
 		return nil, err
 	}
 	return result, nil
 }
+
+func (r *ConfluentSchemaRegistry) requestJSON(ctx context.Context, method string, p string, query url.Values, body io.ReadCloser, result interface{}) error {
+	var payload []byte
+	if body != nil {
+		b, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return err
+		}
+		payload = b
+	}
+
+	urls := r.registryURLs()
+	start := int(atomic.LoadUint32(&r.current))
+	for attempt := 0; ; attempt++ {
+		i := (start + attempt) % len(urls)
+		retryable, err := r.requestOnce(ctx, urls[i], method, p, query, payload, result)
+		if err == nil {
+			atomic.StoreUint32(&r.current, uint32(i))
+			return nil
+		}
+		if !retryable || ctx.Err() != nil {
+			return err
+		}
+		if attempt+1 < len(urls) {
+			r.logf("Request to %s failed, failing over: %v\n", urls[i], err)
+			continue
+		}
+		retry := attempt + 1 - len(urls)
+		if retry >= r.Retry.MaxRetries {
+			return err
+		}
+		backoff := r.Retry.backoff(retry)
+		r.logf("Request to %s failed, retrying in %v: %v\n", urls[i], backoff, err)
+		if err := sleepContext(ctx, backoff); err != nil {
+			return err
+		}
+	}
+}
+
+func (r *ConfluentSchemaRegistry) httpClient() HTTPDoer {
+	if r.HTTPClient != nil {
+		return r.HTTPClient
+	}
+	return HTTPClient
+}
+
+func (r *ConfluentSchemaRegistry) logf(format string, v ...interface{}) {
+	if r.Logger != nil {
+		r.Logger.Printf(format, v...)
+	} else if Logger != nil {
+		Logger.Printf(format, v...)
+	}
+}
+
+func (r *ConfluentSchemaRegistry) registryURLs() []string {
+	if r.RegistryURL == "" && len(r.RegistryURLs) > 0 {
+		return r.RegistryURLs
+	}
+	return append([]string{r.RegistryURL}, r.RegistryURLs...)
+}
+
+func (r *ConfluentSchemaRegistry) requestOnce(ctx context.Context, registryURL string, method string, p string, query url.Values, payload []byte, result interface{}) (bool, error) {
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return false, err
+	}
+	rawPath := path.Join(u.EscapedPath(), p)
+	u.Path, err = url.PathUnescape(rawPath)
+	if err != nil {
+		return false, err
+	}
+	u.RawPath = rawPath
+	u.RawQuery = query.Encode()
+	for refreshed := false; ; refreshed = true {
+		h := http.Header{"Content-Type": {"application/json"}}
+		req := (&http.Request{Method: method, URL: u, Header: h}).WithContext(ctx)
+		if payload != nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(payload))
+		}
+		token, err := r.setAuthHeaders(ctx, req)
+		if err != nil {
+			return false, err
+		}
+		started := time.Now()
+		retryable, status, err := r.do(req, result)
+		emit(r.Hooks, Event{Type: EventHTTPRequest, Method: method, URL: u.Redacted(), Endpoint: endpointOf(p), StatusCode: status, Duration: time.Since(started), Err: err})
+		source, ok := r.TokenSource.(RefreshableTokenSource)
+		if status != http.StatusUnauthorized || !ok || refreshed {
+			return retryable, err
+		}
+		source.Invalidate(token)
+	}
+}
+
+func (r *ConfluentSchemaRegistry) do(req *http.Request, result interface{}) (bool, int, error) {
+	res, err := r.httpClient().Do(req)
+	if err != nil {
+		return true, 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		data := make(map[string]interface{})
+		json.NewDecoder(res.Body).Decode(&data)
+		return res.StatusCode >= http.StatusInternalServerError, res.StatusCode, newRegistryError(res.StatusCode, data)
+	}
+	return false, res.StatusCode, json.NewDecoder(res.Body).Decode(result)
+}
@@ -0,0 +1,105 @@
+package avroturf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strings"
+)
+
+type CompatibilityLevel string
+
+const (
+	CompatibilityBackward           CompatibilityLevel = "BACKWARD"
+	CompatibilityBackwardTransitive CompatibilityLevel = "BACKWARD_TRANSITIVE"
+	CompatibilityForward            CompatibilityLevel = "FORWARD"
+	CompatibilityForwardTransitive  CompatibilityLevel = "FORWARD_TRANSITIVE"
+	CompatibilityFull               CompatibilityLevel = "FULL"
+	CompatibilityFullTransitive     CompatibilityLevel = "FULL_TRANSITIVE"
+	CompatibilityNone               CompatibilityLevel = "NONE"
+)
+
+func (l CompatibilityLevel) Valid() bool {
+	switch l {
+	case CompatibilityBackward, CompatibilityBackwardTransitive,
+		CompatibilityForward, CompatibilityForwardTransitive,
+		CompatibilityFull, CompatibilityFullTransitive,
+		CompatibilityNone:
+		return true
+	}
+	return false
+}
+
+func (r *ConfluentSchemaRegistry) CheckCompatibility(subject string, version int, schema *Schema) (bool, []string, error) {
+	return r.CheckCompatibilityContext(context.Background(), subject, version, schema)
+}
+
+func (r *ConfluentSchemaRegistry) CheckCompatibilityContext(ctx context.Context, subject string, version int, schema *Schema) (bool, []string, error) {
+	body, err := schemaBody(schema)
+	if err != nil {
+		return false, nil, err
+	}
+	res := struct {
+		IsCompatible bool     `json:"is_compatible"`
+		Messages     []string `json:"messages"`
+	}{}
+	p := "/compatibility" + subjectPath(subject, "versions", versionString(version))
+	err = r.requestJSON(ctx, "POST", p, url.Values{"verbose": {"true"}}, body, &res)
+	if err != nil {
+		return false, nil, err
+	}
+	return res.IsCompatible, res.Messages, nil
+}
+
+func (r *ConfluentSchemaRegistry) GetCompatibility(subject string) (CompatibilityLevel, error) {
+	return r.GetCompatibilityContext(context.Background(), subject)
+}
+
+func (r *ConfluentSchemaRegistry) GetCompatibilityContext(ctx context.Context, subject string) (CompatibilityLevel, error) {
+	res := struct {
+		CompatibilityLevel CompatibilityLevel `json:"compatibilityLevel"`
+	}{}
+	var query url.Values
+	if subject != "" {
+		query = url.Values{"defaultToGlobal": {"true"}}
+	}
+	err := r.requestJSON(ctx, "GET", configPath(subject), query, nil, &res)
+	if subject != "" && hasErrorCode(err, ErrorCodeSubjectLevelNotConfigured) {
+		return r.GetCompatibilityContext(ctx, "")
+	}
+	if err != nil {
+		return "", err
+	}
+	return res.CompatibilityLevel, nil
+}
+
+func (r *ConfluentSchemaRegistry) SetCompatibility(subject string, level CompatibilityLevel) (CompatibilityLevel, error) {
+	return r.SetCompatibilityContext(context.Background(), subject, level)
+}
+
+func (r *ConfluentSchemaRegistry) SetCompatibilityContext(ctx context.Context, subject string, level CompatibilityLevel) (CompatibilityLevel, error) {
+	if !level.Valid() {
+		return "", fmt.Errorf("invalid compatibility level: %s", level)
+	}
+	b, err := json.Marshal(map[string]CompatibilityLevel{"compatibility": level})
+	if err != nil {
+		return "", err
+	}
+	res := struct {
+		Compatibility CompatibilityLevel `json:"compatibility"`
+	}{}
+	err = r.requestJSON(ctx, "PUT", configPath(subject), nil, ioutil.NopCloser(strings.NewReader(string(b))), &res)
+	if err != nil {
+		return "", err
+	}
+	return res.Compatibility, nil
+}
+
+func configPath(subject string) string {
+	if subject == "" {
+		return "/config"
+	}
+	return "/config/" + pathSegment(subject)
+}
@@ -0,0 +1,141 @@
+package avroturf_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if expected := "http://schema-registry:8081/compatibility/subjects/TestRecord/versions/latest?verbose=true"; req.URL.String() != expected {
+				t.Errorf("expected '%s' but got '%s'", expected, req.URL)
+			}
+			if req.Method != "POST" {
+				t.Errorf("expected 'POST' but got '%s'", req.Method)
+			}
+			body := `{"is_compatible":false,"messages":["reader field removed"]}`
+			return &http.Response{
+				Body: &stubReadCloser{
+					body: []byte(body),
+				},
+			}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:  client,
+		RegistryURL: "http://schema-registry:8081",
+	}
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compatible, messages, err := r.CheckCompatibility("TestRecord", avroturf.LatestVersion, schema)
+	if err != nil {
+		t.Error(err)
+	}
+	if compatible {
+		t.Error("expected incompatible but got compatible")
+	}
+	if expected := []string{"reader field removed"}; !reflect.DeepEqual(expected, messages) {
+		t.Errorf("expected %v but got %v", expected, messages)
+	}
+}
+
+func TestGetCompatibility(t *testing.T) {
+	r := &avroturf.ConfluentSchemaRegistry{
+		RegistryURL: "http://schema-registry:8081",
+	}
+	stubRegistry(t, r, "GET", "http://schema-registry:8081/config", `{"compatibilityLevel":"BACKWARD"}`)
+	level, err := r.GetCompatibility("")
+	if err != nil {
+		t.Error(err)
+	}
+	if level != avroturf.CompatibilityBackward {
+		t.Errorf("expected %s but got %s", avroturf.CompatibilityBackward, level)
+	}
+
+	stubRegistry(t, r, "GET", "http://schema-registry:8081/config/TestRecord?defaultToGlobal=true", `{"compatibilityLevel":"FULL_TRANSITIVE"}`)
+	level, err = r.GetCompatibility("TestRecord")
+	if err != nil {
+		t.Error(err)
+	}
+	if level != avroturf.CompatibilityFullTransitive {
+		t.Errorf("expected %s but got %s", avroturf.CompatibilityFullTransitive, level)
+	}
+}
+
+func TestGetCompatibilityFallsBackToGlobal(t *testing.T) {
+	var urls []string
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			urls = append(urls, req.URL.String())
+			if req.URL.Path == "/config" {
+				return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`{"compatibilityLevel":"FORWARD"}`))}, nil
+			}
+			body := `{"error_code":40408,"message":"Subject 'TestRecord' does not have subject-level compatibility configured"}`
+			return &http.Response{StatusCode: 404, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:  client,
+		RegistryURL: "http://schema-registry:8081",
+	}
+	level, err := r.GetCompatibility("TestRecord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if level != avroturf.CompatibilityForward {
+		t.Errorf("expected %s but got %s", avroturf.CompatibilityForward, level)
+	}
+	expected := []string{"http://schema-registry:8081/config/TestRecord?defaultToGlobal=true", "http://schema-registry:8081/config"}
+	if !reflect.DeepEqual(expected, urls) {
+		t.Errorf("expected %v but got %v", expected, urls)
+	}
+}
+
+func TestSetCompatibility(t *testing.T) {
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if expected := "http://schema-registry:8081/config/TestRecord"; req.URL.String() != expected {
+				t.Errorf("expected '%s' but got '%s'", expected, req.URL)
+			}
+			if req.Method != "PUT" {
+				t.Errorf("expected 'PUT' but got '%s'", req.Method)
+			}
+			reqBytes, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			if expected := `{"compatibility":"NONE"}`; string(reqBytes) != expected {
+				t.Errorf("expected %s but got %s", expected, reqBytes)
+			}
+			return &http.Response{
+				Body: &stubReadCloser{
+					body: reqBytes,
+				},
+			}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:  client,
+		RegistryURL: "http://schema-registry:8081",
+	}
+	level, err := r.SetCompatibility("TestRecord", avroturf.CompatibilityNone)
+	if err != nil {
+		t.Error(err)
+	}
+	if level != avroturf.CompatibilityNone {
+		t.Errorf("expected %s but got %s", avroturf.CompatibilityNone, level)
+	}
+
+	_, err = r.SetCompatibility("TestRecord", "UNKNOWN")
+	if err == nil || err.Error() != "invalid compatibility level: UNKNOWN" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package avroturf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+type RegistryMode string
+
+const (
+	ModeReadWrite RegistryMode = "READWRITE"
+	ModeReadOnly  RegistryMode = "READONLY"
+	ModeImport    RegistryMode = "IMPORT"
+)
+
+func (m RegistryMode) Valid() bool {
+	switch m {
+	case ModeReadWrite, ModeReadOnly, ModeImport:
+		return true
+	}
+	return false
+}
+
+func (r *ConfluentSchemaRegistry) GetMode(subject string) (RegistryMode, error) {
+	return r.GetModeContext(context.Background(), subject)
+}
+
+func (r *ConfluentSchemaRegistry) GetModeContext(ctx context.Context, subject string) (RegistryMode, error) {
+	res := struct {
+		Mode RegistryMode `json:"mode"`
+	}{}
+	err := r.requestJSON(ctx, "GET", modePath(subject), nil, nil, &res)
+	if err != nil {
+		return "", err
+	}
+	return res.Mode, nil
+}
+
+func (r *ConfluentSchemaRegistry) SetMode(subject string, mode RegistryMode) (RegistryMode, error) {
+	return r.SetModeContext(context.Background(), subject, mode)
+}
+
+func (r *ConfluentSchemaRegistry) SetModeContext(ctx context.Context, subject string, mode RegistryMode) (RegistryMode, error) {
+	if !mode.Valid() {
+		return "", fmt.Errorf("invalid mode: %s", mode)
+	}
+	b, err := json.Marshal(map[string]RegistryMode{"mode": mode})
+	if err != nil {
+		return "", err
+	}
+	res := struct {
+		Mode RegistryMode `json:"mode"`
+	}{}
+	err = r.requestJSON(ctx, "PUT", modePath(subject), nil, ioutil.NopCloser(strings.NewReader(string(b))), &res)
+	if err != nil {
+		return "", err
+	}
+	return res.Mode, nil
+}
+
+func (r *ConfluentSchemaRegistry) DeleteMode(subject string) (RegistryMode, error) {
+	return r.DeleteModeContext(context.Background(), subject)
+}
+
+func (r *ConfluentSchemaRegistry) DeleteModeContext(ctx context.Context, subject string) (RegistryMode, error) {
+	res := struct {
+		Mode RegistryMode `json:"mode"`
+	}{}
+	err := r.requestJSON(ctx, "DELETE", modePath(subject), nil, nil, &res)
+	if err != nil {
+		return "", err
+	}
+	return res.Mode, nil
+}
+
+func (r *ConfluentSchemaRegistry) RegisterWithID(subject string, schema *Schema, schemaID uint32, version int) (uint32, error) {
+	return r.RegisterWithIDContext(context.Background(), subject, schema, schemaID, version)
+}
+
+func (r *ConfluentSchemaRegistry) RegisterWithIDContext(ctx context.Context, subject string, schema *Schema, schemaID uint32, version int) (uint32, error) {
+	body := map[string]interface{}{"schema": schema.String(), "id": schemaID}
+	if version > 0 {
+		body["version"] = version
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+	res := struct {
+		ID uint32 `json:"id"`
+	}{}
+	err = r.requestJSON(ctx, "POST", subjectPath(subject, "versions"), nil, ioutil.NopCloser(strings.NewReader(string(b))), &res)
+	if err != nil {
+		return 0, err
+	}
+	r.logf("Imported schema for subject `%s`; id = %d\n", subject, res.ID)
+	return res.ID, nil
+}
+
+func modePath(subject string) string {
+	if subject == "" {
+		return "/mode"
+	}
+	return "/mode/" + pathSegment(subject)
+}
@@ -1,10 +1,14 @@
 package avroturf_test
 
 import (
+	"context"
+	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/wanabe/avroturf-go"
 )
@@ -18,6 +22,9 @@ type httpClientStub struct {
 }
 
 func (r *stubReadCloser) Read(p []byte) (n int, err error) {
+	if len(r.body) == 0 {
+		return 0, io.EOF
+	}
 	l := copy(p, r.body)
 	r.body = r.body[l:]
 	return l, nil
@@ -32,7 +39,7 @@ func (c *httpClientStub) Do(req *http.Request) (*http.Response, error) {
 }
 
 func TestFetchSchema(t *testing.T) {
-	avroturf.HTTPClient = &httpClientStub{
+	client := &httpClientStub{
 		DoFunc: func(req *http.Request) (*http.Response, error) {
 			if expected := "http://schema-registry:8081/schemas/ids/135"; req.URL.String() != expected {
 				t.Errorf("expected '%s' but got '%s'", expected, req.URL)
@@ -46,6 +53,7 @@ func TestFetchSchema(t *testing.T) {
 		},
 	}
 	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:  client,
 		RegistryURL: "http://schema-registry:8081",
 	}
 	s, err := r.FetchSchema(uint32(135))
@@ -73,7 +81,7 @@ func TestFetchSchema(t *testing.T) {
 }
 
 func TestRegister(t *testing.T) {
-	avroturf.HTTPClient = &httpClientStub{
+	client := &httpClientStub{
 		DoFunc: func(req *http.Request) (*http.Response, error) {
 			if expected := "http://schema-registry:8081/subjects/TestRecord/versions"; req.URL.String() != expected {
 				t.Errorf("expected '%s' but got '%s'", expected, req.URL)
@@ -95,6 +103,7 @@ func TestRegister(t *testing.T) {
 		},
 	}
 	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:  client,
 		RegistryURL: "http://schema-registry:8081",
 	}
 	schema, err := avroturf.Parse(`
@@ -120,3 +129,298 @@ func TestRegister(t *testing.T) {
 		t.Errorf("expected %d but got %d", 135, id)
 	}
 }
+
+func TestFetchSchemaContext(t *testing.T) {
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if v := req.Context().Value(ctxKey{}); v != "value" {
+				t.Errorf("expected request context to be propagated but got %v", v)
+			}
+			if err := req.Context().Err(); err != nil {
+				return nil, err
+			}
+			body := `{"schema":"\"string\""}`
+			return &http.Response{
+				Body: &stubReadCloser{
+					body: []byte(body),
+				},
+			}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:  client,
+		RegistryURL: "http://schema-registry:8081",
+	}
+	s, err := r.FetchSchemaContext(ctx, uint32(135))
+	if err != nil {
+		t.Error(err)
+	}
+	if s.String() != `"string"` {
+		t.Errorf(`expected "string" but got %s`, s)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = r.FetchSchemaContext(ctx, uint32(135))
+	if err != context.Canceled {
+		t.Errorf("expected %v but got %v", context.Canceled, err)
+	}
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Error(err)
+	}
+	_, err = r.RegisterContext(ctx, "TestRecord", schema)
+	if err != context.Canceled {
+		t.Errorf("expected %v but got %v", context.Canceled, err)
+	}
+}
+
+func stubRegistry(t *testing.T, r *avroturf.ConfluentSchemaRegistry, method string, expectedURL string, body string) {
+	r.HTTPClient = &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.Method != method {
+				t.Errorf("expected '%s' but got '%s'", method, req.Method)
+			}
+			if req.URL.String() != expectedURL {
+				t.Errorf("expected '%s' but got '%s'", expectedURL, req.URL)
+			}
+			return &http.Response{
+				Body: &stubReadCloser{
+					body: []byte(body),
+				},
+			}, nil
+		},
+	}
+}
+
+func TestListSubjects(t *testing.T) {
+	r := &avroturf.ConfluentSchemaRegistry{
+		RegistryURL: "http://schema-registry:8081",
+	}
+	stubRegistry(t, r, "GET", "http://schema-registry:8081/subjects", `["subject1","subject2"]`)
+	subjects, err := r.ListSubjects()
+	if err != nil {
+		t.Error(err)
+	}
+	if expected := []string{"subject1", "subject2"}; !reflect.DeepEqual(expected, subjects) {
+		t.Errorf("expected %v but got %v", expected, subjects)
+	}
+}
+
+func TestListVersions(t *testing.T) {
+	r := &avroturf.ConfluentSchemaRegistry{
+		RegistryURL: "http://schema-registry:8081",
+	}
+	stubRegistry(t, r, "GET", "http://schema-registry:8081/subjects/a%2Fb/versions", `[1,2,3]`)
+	versions, err := r.ListVersions("a/b")
+	if err != nil {
+		t.Error(err)
+	}
+	if expected := []int{1, 2, 3}; !reflect.DeepEqual(expected, versions) {
+		t.Errorf("expected %v but got %v", expected, versions)
+	}
+}
+
+func TestDotSubjectPaths(t *testing.T) {
+	r := &avroturf.ConfluentSchemaRegistry{
+		RegistryURL: "http://schema-registry:8081",
+	}
+	for subject, expected := range map[string]string{
+		"..": "http://schema-registry:8081/subjects/%2E%2E/versions",
+		".":  "http://schema-registry:8081/subjects/%2E/versions",
+		"a.": "http://schema-registry:8081/subjects/a./versions",
+	} {
+		stubRegistry(t, r, "GET", expected, `[1]`)
+		if _, err := r.ListVersions(subject); err != nil {
+			t.Error(err)
+		}
+	}
+	stubRegistry(t, r, "GET", "http://schema-registry:8081/config/%2E%2E?defaultToGlobal=true", `{"compatibilityLevel":"FULL"}`)
+	if _, err := r.GetCompatibility(".."); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFetchSchemaBySubjectVersion(t *testing.T) {
+	body := `{"subject":"TestRecord","version":2,"id":135,"schema":"\"string\""}`
+	r := &avroturf.ConfluentSchemaRegistry{
+		RegistryURL: "http://schema-registry:8081",
+	}
+	stubRegistry(t, r, "GET", "http://schema-registry:8081/subjects/TestRecord/versions/2", body)
+	s, err := r.FetchSchemaBySubjectVersion("TestRecord", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Subject != "TestRecord" || s.Version != 2 || s.ID != 135 || s.Schema.String() != `"string"` {
+		t.Errorf("unexpected result: %+v", s)
+	}
+
+	stubRegistry(t, r, "GET", "http://schema-registry:8081/subjects/TestRecord/versions/latest", body)
+	_, err = r.FetchSchemaBySubjectVersion("TestRecord", avroturf.LatestVersion)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLookupSchema(t *testing.T) {
+	r := &avroturf.ConfluentSchemaRegistry{
+		RegistryURL: "http://schema-registry:8081",
+	}
+	stubRegistry(t, r, "POST", "http://schema-registry:8081/subjects/TestRecord", `{"subject":"TestRecord","version":1,"id":135,"schema":"\"string\""}`)
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := r.LookupSchema("TestRecord", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Subject != "TestRecord" || s.Version != 1 || s.ID != 135 || s.Schema.String() != `"string"` {
+		t.Errorf("unexpected result: %+v", s)
+	}
+}
+
+func TestDeleteSubject(t *testing.T) {
+	r := &avroturf.ConfluentSchemaRegistry{
+		RegistryURL: "http://schema-registry:8081",
+	}
+	stubRegistry(t, r, "DELETE", "http://schema-registry:8081/subjects/TestRecord", `[1,2]`)
+	versions, err := r.DeleteSubject("TestRecord", false)
+	if err != nil {
+		t.Error(err)
+	}
+	if expected := []int{1, 2}; !reflect.DeepEqual(expected, versions) {
+		t.Errorf("expected %v but got %v", expected, versions)
+	}
+
+	stubRegistry(t, r, "DELETE", "http://schema-registry:8081/subjects/TestRecord?permanent=true", `[1,2]`)
+	_, err = r.DeleteSubject("TestRecord", true)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDeleteSchemaVersion(t *testing.T) {
+	r := &avroturf.ConfluentSchemaRegistry{
+		RegistryURL: "http://schema-registry:8081",
+	}
+	stubRegistry(t, r, "DELETE", "http://schema-registry:8081/subjects/TestRecord/versions/2", `2`)
+	version, err := r.DeleteSchemaVersion("TestRecord", 2, false)
+	if err != nil {
+		t.Error(err)
+	}
+	if version != 2 {
+		t.Errorf("expected 2 but got %d", version)
+	}
+
+	stubRegistry(t, r, "DELETE", "http://schema-registry:8081/subjects/TestRecord/versions/latest?permanent=true", `3`)
+	version, err = r.DeleteSchemaVersion("TestRecord", avroturf.LatestVersion, true)
+	if err != nil {
+		t.Error(err)
+	}
+	if version != 3 {
+		t.Errorf("expected 3 but got %d", version)
+	}
+}
+
+func TestRegistryFailover(t *testing.T) {
+	var hosts []string
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			hosts = append(hosts, req.URL.Host)
+			if req.URL.Host == "registry1:8081" {
+				return nil, errors.New("connection refused")
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       &stubReadCloser{body: []byte(`{"schema":"\"string\""}`)},
+			}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:   client,
+		RegistryURLs: []string{"http://registry1:8081", "http://registry2:8081"},
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := r.FetchSchema(135); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if expected := []string{"registry1:8081", "registry2:8081", "registry2:8081"}; !reflect.DeepEqual(expected, hosts) {
+		t.Errorf("expected %v but got %v", expected, hosts)
+	}
+}
+
+func TestRegistryRetry(t *testing.T) {
+	statuses := []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK}
+	calls := 0
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			status := statuses[calls]
+			calls++
+			body := `{"error_code":50301,"message":"unavailable"}`
+			if status == http.StatusOK {
+				reqBytes, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					t.Error(err)
+				}
+				if expected := `{"schema":"\"string\""}`; string(reqBytes) != expected {
+					t.Errorf("expected body to be resent but got %s", reqBytes)
+				}
+				body = `{"id":135}`
+			}
+			return &http.Response{StatusCode: status, Body: &stubReadCloser{body: []byte(body)}}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:  client,
+		RegistryURL: "http://schema-registry:8081",
+		Retry:       avroturf.RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond},
+	}
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Register("TestRecord", schema)
+	var registryErr *avroturf.RegistryError
+	if !errors.As(err, &registryErr) || registryErr.StatusCode != http.StatusBadGateway {
+		t.Errorf("expected bad gateway error after exhausting retries but got %v", err)
+	}
+
+	calls = 0
+	r.Retry.MaxRetries = 2
+	id, err := r.Register("TestRecord", schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 135 || calls != 3 {
+		t.Errorf("expected id 135 after 3 calls but got %d after %d calls", id, calls)
+	}
+}
+
+func TestRegistryNoRetryOnClientError(t *testing.T) {
+	calls := 0
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			calls++
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       &stubReadCloser{body: []byte(`{"error_code":40403,"message":"Schema not found"}`)},
+			}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:   client,
+		RegistryURLs: []string{"http://registry1:8081", "http://registry2:8081"},
+		Retry:        avroturf.RetryPolicy{MaxRetries: 3, InitialBackoff: time.Millisecond},
+	}
+	_, err := r.FetchSchema(135)
+	if !avroturf.IsSchemaNotFound(err) {
+		t.Errorf("expected schema not found but got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call but got %d", calls)
+	}
+}
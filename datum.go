@@ -0,0 +1,459 @@
+package avroturf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/hamba/avro"
+)
+
+// Generic datums use the following Go representations:
+// null: nil, boolean: bool, int: int32, long: int64, float: float32,
+// double: float64, bytes and fixed: []byte, string and enum: string,
+// array: []interface{}, map and record: map[string]interface{}.
+// A non-null union value is a single-entry map keyed by the branch name.
+
+func unionBranchName(schema avro.Schema) string {
+	if n, ok := schema.(avro.NamedSchema); ok {
+		return n.FullName()
+	}
+	return string(schema.Type())
+}
+
+func derefSchema(schema avro.Schema) avro.Schema {
+	if ref, ok := schema.(*avro.RefSchema); ok {
+		return ref.Schema()
+	}
+	return schema
+}
+
+func readDatum(r *avro.Reader, schema avro.Schema) (interface{}, error) {
+	v := readValue(r, derefSchema(schema))
+	if r.Error != nil {
+		return nil, r.Error
+	}
+	return v, nil
+}
+
+func readValue(r *avro.Reader, schema avro.Schema) interface{} {
+	if r.Error != nil {
+		return nil
+	}
+	switch s := schema.(type) {
+	case *avro.NullSchema:
+		return nil
+	case *avro.PrimitiveSchema:
+		switch s.Type() {
+		case avro.Boolean:
+			return r.ReadBool()
+		case avro.Int:
+			return r.ReadInt()
+		case avro.Long:
+			return r.ReadLong()
+		case avro.Float:
+			return r.ReadFloat()
+		case avro.Double:
+			return r.ReadDouble()
+		case avro.Bytes:
+			return r.ReadBytes()
+		case avro.String:
+			return r.ReadString()
+		}
+	case *avro.RefSchema:
+		return readValue(r, s.Schema())
+	case *avro.RecordSchema:
+		obj := make(map[string]interface{}, len(s.Fields()))
+		for _, field := range s.Fields() {
+			obj[field.Name()] = readValue(r, field.Type())
+		}
+		return obj
+	case *avro.EnumSchema:
+		idx := int(r.ReadInt())
+		if idx < 0 || idx >= len(s.Symbols()) {
+			r.ReportError("read enum", fmt.Sprintf("unknown enum index: %d", idx))
+			return nil
+		}
+		return s.Symbols()[idx]
+	case *avro.ArraySchema:
+		arr := []interface{}{}
+		r.ReadArrayCB(func(r *avro.Reader) bool {
+			arr = append(arr, readValue(r, s.Items()))
+			return r.Error == nil
+		})
+		return arr
+	case *avro.MapSchema:
+		obj := map[string]interface{}{}
+		r.ReadMapCB(func(r *avro.Reader, key string) bool {
+			obj[key] = readValue(r, s.Values())
+			return r.Error == nil
+		})
+		return obj
+	case *avro.UnionSchema:
+		types := s.Types()
+		idx := int(r.ReadLong())
+		if idx < 0 || idx >= len(types) {
+			r.ReportError("read union", fmt.Sprintf("unknown union index: %d", idx))
+			return nil
+		}
+		branch := derefSchema(types[idx])
+		if branch.Type() == avro.Null {
+			return nil
+		}
+		return map[string]interface{}{unionBranchName(branch): readValue(r, branch)}
+	case *avro.FixedSchema:
+		buf := make([]byte, s.Size())
+		r.Read(buf)
+		return buf
+	}
+	r.ReportError("read", fmt.Sprintf("unexpected schema type: %s", schema.Type()))
+	return nil
+}
+
+func writeDatum(w *avro.Writer, schema avro.Schema, v interface{}) error {
+	if err := writeValue(w, derefSchema(schema), v); err != nil {
+		return err
+	}
+	return w.Error
+}
+
+func writeValue(w *avro.Writer, schema avro.Schema, v interface{}) error {
+	switch s := schema.(type) {
+	case *avro.NullSchema:
+		if v != nil {
+			return fmt.Errorf("expected null but got %T", v)
+		}
+		return nil
+	case *avro.PrimitiveSchema:
+		return writePrimitive(w, s, v)
+	case *avro.RefSchema:
+		return writeValue(w, s.Schema(), v)
+	case *avro.RecordSchema:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected map for record %s but got %T", s.FullName(), v)
+		}
+		for _, field := range s.Fields() {
+			fv, hit := obj[field.Name()]
+			if !hit {
+				if !field.HasDefault() {
+					return fmt.Errorf("missing field %s.%s", s.FullName(), field.Name())
+				}
+				def, err := defaultDatum(field.Type(), field.Default())
+				if err != nil {
+					return err
+				}
+				fv = def
+			}
+			if err := writeValue(w, field.Type(), fv); err != nil {
+				return fmt.Errorf("%s.%s: %v", s.FullName(), field.Name(), err)
+			}
+		}
+		return nil
+	case *avro.EnumSchema:
+		str, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("expected string for enum %s but got %T", s.FullName(), v)
+		}
+		for i, symbol := range s.Symbols() {
+			if symbol == str {
+				w.WriteInt(int32(i))
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown symbol %q for enum %s", str, s.FullName())
+	case *avro.ArraySchema:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected slice for array but got %T", v)
+		}
+		if len(arr) > 0 {
+			w.WriteBlockHeader(int64(len(arr)), -1)
+			for _, item := range arr {
+				if err := writeValue(w, s.Items(), item); err != nil {
+					return err
+				}
+			}
+		}
+		w.WriteBlockHeader(0, -1)
+		return nil
+	case *avro.MapSchema:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected map for map but got %T", v)
+		}
+		if len(obj) > 0 {
+			keys := make([]string, 0, len(obj))
+			for key := range obj {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			w.WriteBlockHeader(int64(len(obj)), -1)
+			for _, key := range keys {
+				w.WriteString(key)
+				if err := writeValue(w, s.Values(), obj[key]); err != nil {
+					return err
+				}
+			}
+		}
+		w.WriteBlockHeader(0, -1)
+		return nil
+	case *avro.UnionSchema:
+		name, val, err := unionBranch(v)
+		if err != nil {
+			return err
+		}
+		branch, idx := findUnionBranch(s, name)
+		if branch == nil {
+			return fmt.Errorf("unknown union branch: %s", name)
+		}
+		w.WriteLong(int64(idx))
+		return writeValue(w, branch, val)
+	case *avro.FixedSchema:
+		buf, ok := v.([]byte)
+		if !ok || len(buf) != s.Size() {
+			return fmt.Errorf("expected %d bytes for fixed %s but got %v", s.Size(), s.FullName(), v)
+		}
+		w.Write(buf)
+		return nil
+	}
+	return fmt.Errorf("unexpected schema type: %s", schema.Type())
+}
+
+func findUnionBranch(union *avro.UnionSchema, name string) (avro.Schema, int) {
+	for i, t := range union.Types() {
+		t = derefSchema(t)
+		if unionBranchName(t) == name {
+			return t, i
+		}
+	}
+	return nil, -1
+}
+
+func unionBranch(v interface{}) (string, interface{}, error) {
+	if v == nil {
+		return string(avro.Null), nil, nil
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok || len(obj) != 1 {
+		return "", nil, fmt.Errorf("expected single-entry map for union but got %v", v)
+	}
+	for name, val := range obj {
+		return name, val, nil
+	}
+	return "", nil, nil
+}
+
+func writePrimitive(w *avro.Writer, s *avro.PrimitiveSchema, v interface{}) error {
+	switch s.Type() {
+	case avro.Boolean:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("expected bool but got %T", v)
+		}
+		w.WriteBool(b)
+	case avro.Int:
+		i, ok := toInt64(v)
+		if !ok || i < math.MinInt32 || i > math.MaxInt32 {
+			return fmt.Errorf("expected int but got %v (%T)", v, v)
+		}
+		w.WriteInt(int32(i))
+	case avro.Long:
+		i, ok := toInt64(v)
+		if !ok {
+			return fmt.Errorf("expected long but got %v (%T)", v, v)
+		}
+		w.WriteLong(i)
+	case avro.Float:
+		f, ok := toFloat64(v)
+		if !ok {
+			return fmt.Errorf("expected float but got %T", v)
+		}
+		w.WriteFloat(float32(f))
+	case avro.Double:
+		f, ok := toFloat64(v)
+		if !ok {
+			return fmt.Errorf("expected double but got %T", v)
+		}
+		w.WriteDouble(f)
+	case avro.Bytes:
+		switch b := v.(type) {
+		case []byte:
+			w.WriteBytes(b)
+		case string:
+			w.WriteBytes([]byte(b))
+		default:
+			return fmt.Errorf("expected bytes but got %T", v)
+		}
+	case avro.String:
+		switch str := v.(type) {
+		case string:
+			w.WriteString(str)
+		case []byte:
+			w.WriteString(string(str))
+		default:
+			return fmt.Errorf("expected string but got %T", v)
+		}
+	default:
+		return fmt.Errorf("unexpected primitive type: %s", s.Type())
+	}
+	return nil
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch i := v.(type) {
+	case int:
+		return int64(i), true
+	case int8:
+		return int64(i), true
+	case int16:
+		return int64(i), true
+	case int32:
+		return int64(i), true
+	case int64:
+		return i, true
+	case uint8:
+		return int64(i), true
+	case uint16:
+		return int64(i), true
+	case uint32:
+		return int64(i), true
+	case float64:
+		if i != math.Trunc(i) {
+			return 0, false
+		}
+		return int64(i), true
+	}
+	return 0, false
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch f := v.(type) {
+	case float32:
+		return float64(f), true
+	case float64:
+		return f, true
+	}
+	if i, ok := toInt64(v); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+// defaultDatum converts a field default as parsed by hamba/avro into a generic
+// datum. Defaults of unions always use the first branch, and bytes and fixed
+// defaults are ISO-8859-1 strings.
+func defaultDatum(schema avro.Schema, def interface{}) (interface{}, error) {
+	switch s := derefSchema(schema).(type) {
+	case *avro.NullSchema:
+		return nil, nil
+	case *avro.PrimitiveSchema:
+		switch s.Type() {
+		case avro.Boolean, avro.String:
+			return def, nil
+		case avro.Int:
+			i, ok := toInt64(def)
+			if !ok {
+				return nil, fmt.Errorf("invalid int default: %v", def)
+			}
+			return int32(i), nil
+		case avro.Long:
+			i, ok := toInt64(def)
+			if !ok {
+				return nil, fmt.Errorf("invalid long default: %v", def)
+			}
+			return i, nil
+		case avro.Float:
+			f, ok := toFloat64(def)
+			if !ok {
+				return nil, fmt.Errorf("invalid float default: %v", def)
+			}
+			return float32(f), nil
+		case avro.Double:
+			f, ok := toFloat64(def)
+			if !ok {
+				return nil, fmt.Errorf("invalid double default: %v", def)
+			}
+			return f, nil
+		case avro.Bytes:
+			return latin1Bytes(def)
+		}
+	case *avro.FixedSchema:
+		return latin1Bytes(def)
+	case *avro.EnumSchema:
+		return def, nil
+	case *avro.ArraySchema:
+		arr, ok := def.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid array default: %v", def)
+		}
+		items := make([]interface{}, len(arr))
+		for i, item := range arr {
+			v, err := defaultDatum(s.Items(), item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = v
+		}
+		return items, nil
+	case *avro.MapSchema:
+		obj, ok := def.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid map default: %v", def)
+		}
+		values := make(map[string]interface{}, len(obj))
+		for key, val := range obj {
+			v, err := defaultDatum(s.Values(), val)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = v
+		}
+		return values, nil
+	case *avro.RecordSchema:
+		obj, ok := def.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid record default: %v", def)
+		}
+		fields := make(map[string]interface{}, len(s.Fields()))
+		for _, field := range s.Fields() {
+			val, hit := obj[field.Name()]
+			if !hit {
+				val = field.Default()
+			}
+			v, err := defaultDatum(field.Type(), val)
+			if err != nil {
+				return nil, err
+			}
+			fields[field.Name()] = v
+		}
+		return fields, nil
+	case *avro.UnionSchema:
+		first := derefSchema(s.Types()[0])
+		if first.Type() == avro.Null {
+			return nil, nil
+		}
+		v, err := defaultDatum(first, def)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{unionBranchName(first): v}, nil
+	}
+	return nil, fmt.Errorf("unsupported default for %s: %v", schema.Type(), def)
+}
+
+func latin1Bytes(v interface{}) ([]byte, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid bytes default: %v", v)
+	}
+	runes := []rune(str)
+	b := make([]byte, len(runes))
+	for i, r := range runes {
+		if r > 0xff {
+			return nil, fmt.Errorf("invalid bytes default: %q", str)
+		}
+		b[i] = byte(r)
+	}
+	return b, nil
+}
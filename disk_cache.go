@@ -0,0 +1,144 @@
+package avroturf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type DiskCache struct {
+	Path   string
+	Logger *log.Logger
+}
+
+func NewDiskCache(path string) *DiskCache {
+	return &DiskCache{Path: path}
+}
+
+func (c *DiskCache) LookupSchemaByID(schemaID uint32) *Schema {
+	return c.readSchema(c.schemaPath(schemaID))
+}
+
+func (c *DiskCache) StoreSchemaByID(schemaID uint32, schema *Schema) *Schema {
+	c.write(c.schemaPath(schemaID), schema.String())
+	return schema
+}
+
+func (c *DiskCache) LookupIdBySchema(subject string, schema *Schema) uint32 {
+	id, err := strconv.ParseUint(c.read(c.schemaKeyPath("ids", subject, schema)), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(id)
+}
+
+func (c *DiskCache) StoreIdBySchema(subject string, schema *Schema, schemaID uint32) uint32 {
+	c.write(c.schemaKeyPath("ids", subject, schema), strconv.FormatUint(uint64(schemaID), 10))
+	return schemaID
+}
+
+func (c *DiskCache) LookupSchemaBySubjectVersion(subject string, version int) *Schema {
+	return c.readSchema(c.subjectVersionPath(subject, version))
+}
+
+func (c *DiskCache) StoreSchemaBySubjectVersion(subject string, version int, schema *Schema) *Schema {
+	c.write(c.subjectVersionPath(subject, version), schema.String())
+	return schema
+}
+
+func (c *DiskCache) LookupVersionBySchema(subject string, schema *Schema) int {
+	version, err := strconv.Atoi(c.read(c.schemaKeyPath("versions", subject, schema)))
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+func (c *DiskCache) StoreVersionBySchema(subject string, schema *Schema, version int) int {
+	c.write(c.schemaKeyPath("versions", subject, schema), strconv.Itoa(version))
+	return version
+}
+
+func (c *DiskCache) schemaPath(schemaID uint32) string {
+	return filepath.Join(c.Path, "schemas", strconv.FormatUint(uint64(schemaID), 10)+".avsc")
+}
+
+func (c *DiskCache) schemaKeyPath(kind string, subject string, schema *Schema) string {
+	sum := sha256.Sum256([]byte(subject + schema.String()))
+	return filepath.Join(c.Path, kind, hex.EncodeToString(sum[:]))
+}
+
+func (c *DiskCache) subjectVersionPath(subject string, version int) string {
+	return filepath.Join(c.Path, "subjects", hex.EncodeToString([]byte(subject)), strconv.Itoa(version)+".avsc")
+}
+
+func (c *DiskCache) readSchema(filename string) *Schema {
+	str := c.read(filename)
+	if str == "" {
+		return nil
+	}
+	schema, err := Parse(str)
+	if err != nil {
+		c.logf("Ignoring broken cache file `%s`: %v\n", filename, err)
+		return nil
+	}
+	return schema
+}
+
+func (c *DiskCache) read(filename string) string {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			c.logf("Failed to read cache file `%s`: %v\n", filename, err)
+		}
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func (c *DiskCache) write(filename string, content string) {
+	if err := writeFileAtomic(filename, []byte(content)); err != nil {
+		c.logf("Failed to write cache file `%s`: %v\n", filename, err)
+	}
+}
+
+func writeFileAtomic(filename string, data []byte) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, ".tmp-"+filepath.Base(filename)+"-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (c *DiskCache) logf(format string, v ...interface{}) {
+	if c.Logger != nil {
+		c.Logger.Printf(format, v...)
+	} else if Logger != nil {
+		Logger.Printf(format, v...)
+	}
+}
@@ -0,0 +1,173 @@
+package avroturf_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func TestDiskCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avroturf-disk-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := avroturf.Parse(`
+		{
+			"type": "record",
+			"name": "TestSchema",
+			"fields": [
+				{
+					"type": "string",
+					"name": "str"
+				}
+			]
+		}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := avroturf.NewDiskCache(dir)
+	if schema := c.LookupSchemaByID(135); schema != nil {
+		t.Errorf("expected nil but got %v", schema)
+	}
+	if id := c.LookupIdBySchema("subject1", s); id != 0 {
+		t.Errorf("expected 0 but got %d", id)
+	}
+	if schema := c.LookupSchemaBySubjectVersion("subject1", 1); schema != nil {
+		t.Errorf("expected nil but got %v", schema)
+	}
+	if version := c.LookupVersionBySchema("subject1", s); version != 0 {
+		t.Errorf("expected 0 but got %d", version)
+	}
+
+	c.StoreSchemaByID(135, s)
+	c.StoreIdBySchema("subject1", s, 135)
+	c.StoreSchemaBySubjectVersion("subject1", 1, s)
+	c.StoreVersionBySchema("subject1", s, 1)
+
+	c = avroturf.NewDiskCache(dir)
+	if schema := c.LookupSchemaByID(135); schema == nil || schema.String() != s.String() {
+		t.Errorf("expected %v but got %v", s, schema)
+	}
+	if id := c.LookupIdBySchema("subject1", s); id != 135 {
+		t.Errorf("expected 135 but got %d", id)
+	}
+	if id := c.LookupIdBySchema("subject2", s); id != 0 {
+		t.Errorf("expected 0 but got %d", id)
+	}
+	if schema := c.LookupSchemaBySubjectVersion("subject1", 1); schema == nil || schema.String() != s.String() {
+		t.Errorf("expected %v but got %v", s, schema)
+	}
+	if version := c.LookupVersionBySchema("subject1", s); version != 1 {
+		t.Errorf("expected 1 but got %d", version)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*", ".tmp-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected no temporary files but got %v", files)
+	}
+}
+
+func TestDiskCacheSubjectTraversal(t *testing.T) {
+	root, err := ioutil.TempDir("", "avroturf-disk-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, "cache")
+
+	s, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := avroturf.NewDiskCache(dir)
+	for _, subject := range []string{"..", ".", "../../escaped", "a/b"} {
+		c.StoreSchemaBySubjectVersion(subject, 1, s)
+		if cached := c.LookupSchemaBySubjectVersion(subject, 1); cached == nil || cached.String() != s.String() {
+			t.Errorf("%s: expected %v but got %v", subject, s, cached)
+		}
+	}
+	err = filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && !strings.HasPrefix(p, filepath.Join(dir, "subjects")+string(filepath.Separator)) {
+			t.Errorf("unexpected file outside the subjects directory: %s", p)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiskCacheLogger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avroturf-disk-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	c := &avroturf.DiskCache{Path: dir, Logger: log.New(buf, "", 0)}
+	c.StoreSchemaByID(1, s)
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		return ioutil.WriteFile(p, []byte("{"), 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached := c.LookupSchemaByID(1); cached != nil {
+		t.Errorf("expected broken entry to be ignored but got %v", cached)
+	}
+	if !strings.HasPrefix(buf.String(), "Ignoring broken cache file") {
+		t.Errorf("unexpected log: %q", buf.String())
+	}
+}
+
+func TestDiskCacheOnParallel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avroturf-disk-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := avroturf.NewDiskCache(dir)
+			c.StoreSchemaByID(1, s)
+			if schema := c.LookupSchemaByID(1); schema == nil || schema.String() != s.String() {
+				t.Errorf("expected %v but got %v", s, schema)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,65 @@
+package avroturf
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventSchemaFetched    EventType = "schema_fetched"
+	EventSchemaRegistered EventType = "schema_registered"
+	EventCacheHit         EventType = "cache_hit"
+	EventCacheMiss        EventType = "cache_miss"
+	EventCacheEviction    EventType = "cache_eviction"
+	EventHTTPRequest      EventType = "http_request"
+	EventDecoded          EventType = "decoded"
+	EventDecodeFailed     EventType = "decode_failed"
+	EventEncoded          EventType = "encoded"
+	EventEncodeFailed     EventType = "encode_failed"
+)
+
+type Event struct {
+	Type       EventType
+	SchemaID   uint32
+	Subject    string
+	Version    int
+	Method     string
+	URL        string
+	Endpoint   string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+type Hooks interface {
+	OnEvent(event Event)
+}
+
+type HooksFunc func(event Event)
+
+func (f HooksFunc) OnEvent(event Event) {
+	f(event)
+}
+
+func MultiHooks(hooks ...Hooks) Hooks {
+	return HooksFunc(func(event Event) {
+		for _, h := range hooks {
+			emit(h, event)
+		}
+	})
+}
+
+func emit(hooks Hooks, event Event) {
+	if hooks != nil {
+		hooks.OnEvent(event)
+	}
+}
+
+func schemaIDOf(data []byte) uint32 {
+	if len(data) < 5 {
+		return 0
+	}
+	return binary.BigEndian.Uint32(data[1:5])
+}
@@ -6,4 +6,5 @@ require (
 	github.com/golang/mock v1.4.4
 	github.com/hamba/avro v1.5.2
 	github.com/rakyll/statik v0.1.7
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -1,14 +1,51 @@
 package avroturf
 
 import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 type InMemoryCache struct {
 	SchemasByID             map[uint32]*Schema
 	IdsBySchema             map[string]uint32
 	SchemasBySubjectVersion map[string]*Schema
+	VersionsBySchema        map[string]int
 	sync.Mutex
+
+	MaxEntries        int
+	SubjectVersionTTL time.Duration
+	Hooks             Hooks
+
+	lru         *list.List
+	elements    map[cacheKey]*list.Element
+	expiresAt   map[string]time.Time
+	evictions   uint64
+	expirations uint64
+}
+
+type CacheStats struct {
+	Entries     int
+	Evictions   uint64
+	Expirations uint64
+}
+
+type cacheKind int
+
+const (
+	cacheSchemaByID cacheKind = iota
+	cacheIdBySchema
+	cacheSchemaBySubjectVersion
+	cacheVersionBySchema
+)
+
+type cacheKey struct {
+	kind cacheKind
+	id   uint32
+	key  string
 }
 
 func NewInMemoryCache() *InMemoryCache {
@@ -16,33 +53,204 @@ func NewInMemoryCache() *InMemoryCache {
 		SchemasByID:             map[uint32]*Schema{},
 		IdsBySchema:             map[string]uint32{},
 		SchemasBySubjectVersion: map[string]*Schema{},
+		VersionsBySchema:        map[string]int{},
 	}
 }
 
+func NewBoundedInMemoryCache(maxEntries int, subjectVersionTTL time.Duration) *InMemoryCache {
+	c := NewInMemoryCache()
+	c.MaxEntries = maxEntries
+	c.SubjectVersionTTL = subjectVersionTTL
+	return c
+}
+
 func (c *InMemoryCache) LookupSchemaByID(schemaID uint32) *Schema {
+	var events []Event
+	defer func() { c.emit(events) }()
 	c.Lock()
 	defer c.Unlock()
-	return c.SchemasByID[schemaID]
+	schema, hit := c.SchemasByID[schemaID]
+	if hit {
+		events = c.touch(cacheKey{kind: cacheSchemaByID, id: schemaID})
+	}
+	return schema
 }
 
 func (c *InMemoryCache) StoreSchemaByID(schemaID uint32, schema *Schema) *Schema {
+	var events []Event
+	defer func() { c.emit(events) }()
 	c.Lock()
 	defer c.Unlock()
 	c.SchemasByID[schemaID] = schema
+	events = c.touch(cacheKey{kind: cacheSchemaByID, id: schemaID})
 	return schema
 }
 
 func (c *InMemoryCache) LookupIdBySchema(subject string, schema *Schema) uint32 {
 	key := subject + schema.String()
+	var events []Event
+	defer func() { c.emit(events) }()
 	c.Lock()
 	defer c.Unlock()
-	return c.IdsBySchema[key]
+	schemaID, hit := c.IdsBySchema[key]
+	if hit {
+		events = c.touch(cacheKey{kind: cacheIdBySchema, key: key})
+	}
+	return schemaID
 }
 
 func (c *InMemoryCache) StoreIdBySchema(subject string, schema *Schema, schemaID uint32) uint32 {
 	key := subject + schema.String()
+	var events []Event
+	defer func() { c.emit(events) }()
 	c.Lock()
 	defer c.Unlock()
 	c.IdsBySchema[key] = schemaID
+	events = c.touch(cacheKey{kind: cacheIdBySchema, key: key})
 	return schemaID
 }
+
+func (c *InMemoryCache) LookupSchemaBySubjectVersion(subject string, version int) *Schema {
+	key := subjectVersionKey(subject, version)
+	var events []Event
+	defer func() { c.emit(events) }()
+	c.Lock()
+	defer c.Unlock()
+	schema, hit := c.SchemasBySubjectVersion[key]
+	if !hit {
+		return nil
+	}
+	if expiresAt, ok := c.expiresAt[key]; ok && !time.Now().Before(expiresAt) {
+		k := cacheKey{kind: cacheSchemaBySubjectVersion, key: key}
+		events = append(events, c.evictionEvent(k))
+		c.remove(k)
+		c.expirations++
+		return nil
+	}
+	events = c.touch(cacheKey{kind: cacheSchemaBySubjectVersion, key: key})
+	return schema
+}
+
+func (c *InMemoryCache) StoreSchemaBySubjectVersion(subject string, version int, schema *Schema) *Schema {
+	key := subjectVersionKey(subject, version)
+	var events []Event
+	defer func() { c.emit(events) }()
+	c.Lock()
+	defer c.Unlock()
+	c.SchemasBySubjectVersion[key] = schema
+	if version == LatestVersion && c.SubjectVersionTTL > 0 {
+		if c.expiresAt == nil {
+			c.expiresAt = map[string]time.Time{}
+		}
+		c.expiresAt[key] = time.Now().Add(c.SubjectVersionTTL)
+	}
+	events = c.touch(cacheKey{kind: cacheSchemaBySubjectVersion, key: key})
+	return schema
+}
+
+func (c *InMemoryCache) LookupVersionBySchema(subject string, schema *Schema) int {
+	key := subject + schema.String()
+	var events []Event
+	defer func() { c.emit(events) }()
+	c.Lock()
+	defer c.Unlock()
+	version, hit := c.VersionsBySchema[key]
+	if hit {
+		events = c.touch(cacheKey{kind: cacheVersionBySchema, key: key})
+	}
+	return version
+}
+
+func (c *InMemoryCache) StoreVersionBySchema(subject string, schema *Schema, version int) int {
+	key := subject + schema.String()
+	var events []Event
+	defer func() { c.emit(events) }()
+	c.Lock()
+	defer c.Unlock()
+	c.VersionsBySchema[key] = version
+	events = c.touch(cacheKey{kind: cacheVersionBySchema, key: key})
+	return version
+}
+
+func (c *InMemoryCache) Stats() CacheStats {
+	c.Lock()
+	defer c.Unlock()
+	return CacheStats{
+		Entries:     len(c.SchemasByID) + len(c.IdsBySchema) + len(c.SchemasBySubjectVersion) + len(c.VersionsBySchema),
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+	}
+}
+
+func (c *InMemoryCache) CachesLatestVersion() bool {
+	return c.SubjectVersionTTL > 0
+}
+
+func (c *InMemoryCache) touch(k cacheKey) []Event {
+	if c.MaxEntries <= 0 {
+		return nil
+	}
+	if c.lru == nil {
+		c.lru = list.New()
+		c.elements = map[cacheKey]*list.Element{}
+	}
+	if e, hit := c.elements[k]; hit {
+		c.lru.MoveToFront(e)
+		return nil
+	}
+	c.elements[k] = c.lru.PushFront(k)
+	var events []Event
+	for c.lru.Len() > c.MaxEntries {
+		evicted := c.lru.Back().Value.(cacheKey)
+		events = append(events, c.evictionEvent(evicted))
+		c.remove(evicted)
+		c.evictions++
+	}
+	return events
+}
+
+func (c *InMemoryCache) emit(events []Event) {
+	for _, event := range events {
+		emit(c.Hooks, event)
+	}
+}
+
+func (c *InMemoryCache) evictionEvent(k cacheKey) Event {
+	event := Event{Type: EventCacheEviction, SchemaID: k.id}
+	switch k.kind {
+	case cacheIdBySchema:
+		event.SchemaID = c.IdsBySchema[k.key]
+	case cacheSchemaBySubjectVersion:
+		i := strings.LastIndex(k.key, ":")
+		event.Subject = k.key[:i]
+		event.Version, _ = strconv.Atoi(k.key[i+1:])
+		if schema := c.SchemasBySubjectVersion[k.key]; schema != nil {
+			event.SchemaID = c.IdsBySchema[event.Subject+schema.String()]
+		}
+	case cacheVersionBySchema:
+		event.Version = c.VersionsBySchema[k.key]
+	}
+	return event
+}
+
+func (c *InMemoryCache) remove(k cacheKey) {
+	if e, hit := c.elements[k]; hit {
+		c.lru.Remove(e)
+		delete(c.elements, k)
+	}
+	switch k.kind {
+	case cacheSchemaByID:
+		delete(c.SchemasByID, k.id)
+	case cacheIdBySchema:
+		delete(c.IdsBySchema, k.key)
+	case cacheSchemaBySubjectVersion:
+		delete(c.SchemasBySubjectVersion, k.key)
+		delete(c.expiresAt, k.key)
+	case cacheVersionBySchema:
+		delete(c.VersionsBySchema, k.key)
+	}
+}
+
+func subjectVersionKey(subject string, version int) string {
+	return fmt.Sprintf("%s:%d", subject, version)
+}
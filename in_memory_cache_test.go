@@ -1,8 +1,10 @@
 package avroturf_test
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/wanabe/avroturf-go"
 )
@@ -111,3 +113,148 @@ func TestLookupIdBySchema(t *testing.T) {
 		t.Errorf("expected 0 but got %d", id)
 	}
 }
+
+func TestStoreSchemaBySubjectVersion(t *testing.T) {
+	c := avroturf.NewInMemoryCache()
+	s, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Error(err)
+	}
+	schema := c.LookupSchemaBySubjectVersion("subject1", 1)
+	if schema != nil {
+		t.Errorf("expected nil but got %v", schema)
+	}
+	schema = c.StoreSchemaBySubjectVersion("subject1", 1, s)
+	if schema != s {
+		t.Errorf("expected %v but got %v", s, schema)
+	}
+	schema = c.LookupSchemaBySubjectVersion("subject1", 1)
+	if schema != s {
+		t.Errorf("expected %v but got %v", s, schema)
+	}
+	schema = c.LookupSchemaBySubjectVersion("subject1", 2)
+	if schema != nil {
+		t.Errorf("expected nil but got %v", schema)
+	}
+}
+
+func TestStoreVersionBySchema(t *testing.T) {
+	c := avroturf.NewInMemoryCache()
+	s, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Error(err)
+	}
+	version := c.LookupVersionBySchema("subject1", s)
+	if version != 0 {
+		t.Errorf("expected 0 but got %d", version)
+	}
+	version = c.StoreVersionBySchema("subject1", s, 3)
+	if version != 3 {
+		t.Errorf("expected 3 but got %d", version)
+	}
+	version = c.LookupVersionBySchema("subject1", s)
+	if version != 3 {
+		t.Errorf("expected 3 but got %d", version)
+	}
+	version = c.LookupVersionBySchema("subject2", s)
+	if version != 0 {
+		t.Errorf("expected 0 but got %d", version)
+	}
+}
+
+func TestInMemoryCacheMaxEntries(t *testing.T) {
+	c := avroturf.NewBoundedInMemoryCache(2, 0)
+	var evicted []uint32
+	c.Hooks = avroturf.HooksFunc(func(event avroturf.Event) {
+		evicted = append(evicted, event.SchemaID)
+	})
+	s, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.StoreSchemaByID(1, s)
+	c.StoreSchemaByID(2, s)
+	c.LookupSchemaByID(1)
+	c.StoreSchemaByID(3, s)
+	if c.LookupSchemaByID(2) != nil {
+		t.Error("expected least recently used entry to be evicted")
+	}
+	if c.LookupSchemaByID(1) == nil || c.LookupSchemaByID(3) == nil {
+		t.Error("expected recently used entries to be kept")
+	}
+	c.StoreIdBySchema("subject1", s, 1)
+	if stats := c.Stats(); stats.Entries != 2 || stats.Evictions != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if fmt.Sprint(evicted) != "[2 1]" {
+		t.Errorf("unexpected evicted schema ids: %v", evicted)
+	}
+}
+
+func TestInMemoryCacheSubjectVersionTTL(t *testing.T) {
+	c := avroturf.NewBoundedInMemoryCache(0, 10*time.Millisecond)
+	var events []avroturf.Event
+	c.Hooks = avroturf.HooksFunc(func(event avroturf.Event) {
+		events = append(events, event)
+	})
+	s, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.StoreSchemaBySubjectVersion("subject1", avroturf.LatestVersion, s)
+	c.StoreSchemaBySubjectVersion("subject1", 1, s)
+	c.StoreSchemaByID(1, s)
+	if !c.CachesLatestVersion() {
+		t.Error("expected bounded cache with ttl to cache latest versions")
+	}
+	if c.LookupSchemaBySubjectVersion("subject1", avroturf.LatestVersion) != s {
+		t.Error("expected entry before ttl")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if schema := c.LookupSchemaBySubjectVersion("subject1", avroturf.LatestVersion); schema != nil {
+		t.Errorf("expected nil after ttl but got %v", schema)
+	}
+	if c.LookupSchemaByID(1) != s {
+		t.Error("expected ttl not to apply to schema ids")
+	}
+	if c.LookupSchemaBySubjectVersion("subject1", 1) != s {
+		t.Error("expected ttl not to apply to concrete versions")
+	}
+	if stats := c.Stats(); stats.Entries != 2 || stats.Expirations != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	expected := []avroturf.Event{{Type: avroturf.EventCacheEviction, Subject: "subject1", Version: avroturf.LatestVersion}}
+	if !reflect.DeepEqual(expected, events) {
+		t.Errorf("expected %+v but got %+v", expected, events)
+	}
+}
+
+func TestInMemoryCacheHooksCanReadStats(t *testing.T) {
+	c := avroturf.NewBoundedInMemoryCache(1, time.Millisecond)
+	var stats []avroturf.CacheStats
+	c.Hooks = avroturf.HooksFunc(func(event avroturf.Event) {
+		stats = append(stats, c.Stats())
+	})
+	s, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.StoreSchemaBySubjectVersion("subject1", avroturf.LatestVersion, s)
+		time.Sleep(5 * time.Millisecond)
+		c.LookupSchemaBySubjectVersion("subject1", avroturf.LatestVersion)
+		c.StoreSchemaByID(1, s)
+		c.StoreSchemaByID(2, s)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected hooks to run without holding the cache lock")
+	}
+	expected := []avroturf.CacheStats{{Entries: 0, Expirations: 1}, {Entries: 1, Evictions: 1, Expirations: 1}}
+	if !reflect.DeepEqual(expected, stats) {
+		t.Errorf("expected %+v but got %+v", expected, stats)
+	}
+}
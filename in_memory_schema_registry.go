@@ -0,0 +1,526 @@
+package avroturf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hamba/avro"
+)
+
+type InMemorySchemaRegistry struct {
+	sync.Mutex
+	Compatibility CompatibilityLevel
+	Mode          RegistryMode
+
+	schemas  map[uint32]*Schema
+	maxID    uint32
+	ids      map[string]uint32
+	subjects map[string]*memorySubject
+}
+
+type memorySubject struct {
+	versions      []*memoryVersion
+	compatibility CompatibilityLevel
+	mode          RegistryMode
+}
+
+type memoryVersion struct {
+	version int
+	id      uint32
+	deleted bool
+}
+
+func NewInMemorySchemaRegistry() *InMemorySchemaRegistry {
+	return &InMemorySchemaRegistry{}
+}
+
+func (r *InMemorySchemaRegistry) RegisterSchemaStore(store *SchemaStore) error {
+	fullNames, err := store.FullNames()
+	if err != nil {
+		return err
+	}
+	for _, fullName := range fullNames {
+		namespace, name := "", fullName
+		if i := strings.LastIndex(fullName, "."); i >= 0 {
+			namespace, name = fullName[:i], fullName[i+1:]
+		}
+		schema, err := store.Find(name, namespace)
+		if err != nil {
+			return err
+		}
+		subject := fullName
+		if named, ok := schema.Schema.(avro.NamedSchema); ok {
+			subject = named.FullName()
+		}
+		if _, err := r.Register(subject, schema); err != nil {
+			return fmt.Errorf("%s: %v", fullName, err)
+		}
+	}
+	return nil
+}
+
+func (r *InMemorySchemaRegistry) FetchSchema(schemaID uint32) (*Schema, error) {
+	return r.FetchSchemaContext(context.Background(), schemaID)
+}
+
+func (r *InMemorySchemaRegistry) FetchSchemaContext(ctx context.Context, schemaID uint32) (*Schema, error) {
+	r.Lock()
+	defer r.Unlock()
+	schema, hit := r.schemas[schemaID]
+	if !hit {
+		return nil, &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeSchemaNotFound, Message: fmt.Sprintf("Schema %d not found", schemaID)}
+	}
+	return schema, nil
+}
+
+func (r *InMemorySchemaRegistry) Register(subject string, schema *Schema) (uint32, error) {
+	return r.RegisterContext(context.Background(), subject, schema)
+}
+
+func (r *InMemorySchemaRegistry) RegisterContext(ctx context.Context, subject string, schema *Schema) (uint32, error) {
+	r.Lock()
+	defer r.Unlock()
+	s := r.lookupSubject(subject)
+	if r.mode(s) == ModeReadOnly {
+		return 0, &RegistryError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: ErrorCodeOperationNotPermitted, Message: fmt.Sprintf("Subject %s is in read-only mode", subject)}
+	}
+	if v := r.findVersion(s, schema); v != nil {
+		return v.id, nil
+	}
+	var previous []*Schema
+	for _, v := range s.live() {
+		previous = append(previous, r.schemas[v.id])
+	}
+	if incompatibilities := CheckCompatibilityLevel(r.level(s), schema, previous); len(incompatibilities) > 0 {
+		return 0, &RegistryError{StatusCode: http.StatusConflict, ErrorCode: ErrorCodeIncompatibleSchema, Message: fmt.Sprintf("Schema being registered is incompatible with an earlier schema for subject %q: %v", subject, incompatibilities)}
+	}
+
+	id, hit := r.ids[schema.String()]
+	if !hit {
+		id = r.maxID + 1
+		r.storeSchema(id, schema)
+	}
+	s.versions = append(s.versions, &memoryVersion{version: s.nextVersion(), id: id})
+	r.storeSubject(subject, s)
+	return id, nil
+}
+
+func (r *InMemorySchemaRegistry) RegisterWithID(subject string, schema *Schema, schemaID uint32, version int) (uint32, error) {
+	return r.RegisterWithIDContext(context.Background(), subject, schema, schemaID, version)
+}
+
+func (r *InMemorySchemaRegistry) RegisterWithIDContext(ctx context.Context, subject string, schema *Schema, schemaID uint32, version int) (uint32, error) {
+	r.Lock()
+	defer r.Unlock()
+	s := r.lookupSubject(subject)
+	if r.mode(s) != ModeImport {
+		return 0, &RegistryError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: ErrorCodeOperationNotPermitted, Message: fmt.Sprintf("Subject %s is not in import mode", subject)}
+	}
+	if existing, hit := r.schemas[schemaID]; hit && existing.String() != schema.String() {
+		return 0, &RegistryError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: ErrorCodeOperationNotPermitted, Message: fmt.Sprintf("Overwrite new schema with id %d is not permitted", schemaID)}
+	}
+	if version <= 0 {
+		version = s.nextVersion()
+	}
+	for _, v := range s.versions {
+		if v.version != version {
+			continue
+		}
+		if v.id != schemaID {
+			return 0, &RegistryError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: ErrorCodeOperationNotPermitted, Message: fmt.Sprintf("Overwrite new schema for subject %s version %d is not permitted", subject, version)}
+		}
+		return schemaID, nil
+	}
+	r.storeSchema(schemaID, schema)
+	s.versions = append(s.versions, &memoryVersion{version: version, id: schemaID})
+	r.storeSubject(subject, s)
+	sort.Slice(s.versions, func(i, j int) bool { return s.versions[i].version < s.versions[j].version })
+	return schemaID, nil
+}
+
+func (r *InMemorySchemaRegistry) GetMode(subject string) (RegistryMode, error) {
+	return r.GetModeContext(context.Background(), subject)
+}
+
+func (r *InMemorySchemaRegistry) GetModeContext(ctx context.Context, subject string) (RegistryMode, error) {
+	r.Lock()
+	defer r.Unlock()
+	if subject == "" {
+		return r.globalMode(), nil
+	}
+	s, hit := r.subjects[subject]
+	if !hit {
+		return "", subjectNotFound(subject)
+	}
+	if s.mode == "" {
+		return "", &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeSubjectModeNotConfigured, Message: fmt.Sprintf("Subject '%s' does not have subject-level mode configured", subject)}
+	}
+	return s.mode, nil
+}
+
+func (r *InMemorySchemaRegistry) SetMode(subject string, mode RegistryMode) (RegistryMode, error) {
+	return r.SetModeContext(context.Background(), subject, mode)
+}
+
+func (r *InMemorySchemaRegistry) SetModeContext(ctx context.Context, subject string, mode RegistryMode) (RegistryMode, error) {
+	if !mode.Valid() {
+		return "", &RegistryError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: ErrorCodeInvalidMode, Message: fmt.Sprintf("Invalid mode: %s", mode)}
+	}
+	r.Lock()
+	defer r.Unlock()
+	if subject == "" {
+		r.Mode = mode
+	} else {
+		r.ensureSubject(subject).mode = mode
+	}
+	return mode, nil
+}
+
+func (r *InMemorySchemaRegistry) DeleteMode(subject string) (RegistryMode, error) {
+	return r.DeleteModeContext(context.Background(), subject)
+}
+
+func (r *InMemorySchemaRegistry) DeleteModeContext(ctx context.Context, subject string) (RegistryMode, error) {
+	r.Lock()
+	defer r.Unlock()
+	s, hit := r.subjects[subject]
+	if !hit {
+		return "", subjectNotFound(subject)
+	}
+	previous := r.mode(s)
+	s.mode = ""
+	return previous, nil
+}
+
+func (r *InMemorySchemaRegistry) FetchSchemaBySubjectVersion(subject string, version int) (*RegisteredSchema, error) {
+	return r.FetchSchemaBySubjectVersionContext(context.Background(), subject, version)
+}
+
+func (r *InMemorySchemaRegistry) FetchSchemaBySubjectVersionContext(ctx context.Context, subject string, version int) (*RegisteredSchema, error) {
+	r.Lock()
+	defer r.Unlock()
+	s, err := r.subject(subject)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.version(version, false)
+	if err != nil {
+		return nil, err
+	}
+	return r.registeredSchema(subject, v), nil
+}
+
+func (r *InMemorySchemaRegistry) LookupSchema(subject string, schema *Schema) (*RegisteredSchema, error) {
+	return r.LookupSchemaContext(context.Background(), subject, schema)
+}
+
+func (r *InMemorySchemaRegistry) LookupSchemaContext(ctx context.Context, subject string, schema *Schema) (*RegisteredSchema, error) {
+	r.Lock()
+	defer r.Unlock()
+	s, err := r.subject(subject)
+	if err != nil {
+		return nil, err
+	}
+	v := r.findVersion(s, schema)
+	if v == nil {
+		return nil, &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeSchemaNotFound, Message: "Schema not found"}
+	}
+	return r.registeredSchema(subject, v), nil
+}
+
+func (r *InMemorySchemaRegistry) ListSubjects() ([]string, error) {
+	return r.ListSubjectsContext(context.Background())
+}
+
+func (r *InMemorySchemaRegistry) ListSubjectsContext(ctx context.Context) ([]string, error) {
+	r.Lock()
+	defer r.Unlock()
+	subjects := []string{}
+	for name, s := range r.subjects {
+		if len(s.live()) > 0 {
+			subjects = append(subjects, name)
+		}
+	}
+	sort.Strings(subjects)
+	return subjects, nil
+}
+
+func (r *InMemorySchemaRegistry) ListVersions(subject string) ([]int, error) {
+	return r.ListVersionsContext(context.Background(), subject)
+}
+
+func (r *InMemorySchemaRegistry) ListVersionsContext(ctx context.Context, subject string) ([]int, error) {
+	r.Lock()
+	defer r.Unlock()
+	s, err := r.subject(subject)
+	if err != nil {
+		return nil, err
+	}
+	versions := []int{}
+	for _, v := range s.live() {
+		versions = append(versions, v.version)
+	}
+	return versions, nil
+}
+
+func (r *InMemorySchemaRegistry) DeleteSubject(subject string, permanent bool) ([]int, error) {
+	return r.DeleteSubjectContext(context.Background(), subject, permanent)
+}
+
+func (r *InMemorySchemaRegistry) DeleteSubjectContext(ctx context.Context, subject string, permanent bool) ([]int, error) {
+	r.Lock()
+	defer r.Unlock()
+	s, hit := r.subjects[subject]
+	if !hit || len(s.versions) == 0 || (!permanent && len(s.live()) == 0) {
+		return nil, subjectNotFound(subject)
+	}
+	versions := []int{}
+	if permanent {
+		if len(s.live()) > 0 {
+			return nil, &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeSubjectNotSoftDeleted, Message: fmt.Sprintf("Subject '%s' was not deleted first before being permanently deleted", subject)}
+		}
+		for _, v := range s.versions {
+			versions = append(versions, v.version)
+		}
+		delete(r.subjects, subject)
+		return versions, nil
+	}
+	for _, v := range s.live() {
+		v.deleted = true
+		versions = append(versions, v.version)
+	}
+	return versions, nil
+}
+
+func (r *InMemorySchemaRegistry) DeleteSchemaVersion(subject string, version int, permanent bool) (int, error) {
+	return r.DeleteSchemaVersionContext(context.Background(), subject, version, permanent)
+}
+
+func (r *InMemorySchemaRegistry) DeleteSchemaVersionContext(ctx context.Context, subject string, version int, permanent bool) (int, error) {
+	r.Lock()
+	defer r.Unlock()
+	s, hit := r.subjects[subject]
+	if !hit || len(s.versions) == 0 {
+		return 0, subjectNotFound(subject)
+	}
+	v, err := s.version(version, permanent)
+	if err != nil {
+		return 0, err
+	}
+	if !permanent {
+		v.deleted = true
+		return v.version, nil
+	}
+	if !v.deleted {
+		return 0, &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeVersionNotSoftDeleted, Message: fmt.Sprintf("Subject '%s' Version %d was not deleted first before being permanently deleted", subject, v.version)}
+	}
+	for i, candidate := range s.versions {
+		if candidate == v {
+			s.versions = append(s.versions[:i], s.versions[i+1:]...)
+			break
+		}
+	}
+	return v.version, nil
+}
+
+func (r *InMemorySchemaRegistry) CheckCompatibility(subject string, version int, schema *Schema) (bool, []string, error) {
+	return r.CheckCompatibilityContext(context.Background(), subject, version, schema)
+}
+
+func (r *InMemorySchemaRegistry) CheckCompatibilityContext(ctx context.Context, subject string, version int, schema *Schema) (bool, []string, error) {
+	r.Lock()
+	defer r.Unlock()
+	s, err := r.subject(subject)
+	if err != nil {
+		return false, nil, err
+	}
+	v, err := s.version(version, false)
+	if err != nil {
+		return false, nil, err
+	}
+	messages := []string{}
+	for _, incompatibility := range CheckCompatibilityLevel(r.level(s), schema, []*Schema{r.schemas[v.id]}) {
+		messages = append(messages, incompatibility.String())
+	}
+	return len(messages) == 0, messages, nil
+}
+
+func (r *InMemorySchemaRegistry) GetCompatibility(subject string) (CompatibilityLevel, error) {
+	return r.GetCompatibilityContext(context.Background(), subject)
+}
+
+func (r *InMemorySchemaRegistry) GetCompatibilityContext(ctx context.Context, subject string) (CompatibilityLevel, error) {
+	return r.getCompatibility(subject, true)
+}
+
+func (r *InMemorySchemaRegistry) GetSubjectCompatibility(subject string) (CompatibilityLevel, error) {
+	return r.GetSubjectCompatibilityContext(context.Background(), subject)
+}
+
+func (r *InMemorySchemaRegistry) GetSubjectCompatibilityContext(ctx context.Context, subject string) (CompatibilityLevel, error) {
+	return r.getCompatibility(subject, false)
+}
+
+func (r *InMemorySchemaRegistry) getCompatibility(subject string, defaultToGlobal bool) (CompatibilityLevel, error) {
+	r.Lock()
+	defer r.Unlock()
+	if subject == "" {
+		return r.globalLevel(), nil
+	}
+	if s, hit := r.subjects[subject]; hit && s.compatibility != "" {
+		return s.compatibility, nil
+	}
+	if !defaultToGlobal {
+		return "", &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeSubjectLevelNotConfigured, Message: fmt.Sprintf("Subject '%s' does not have subject-level compatibility configured", subject)}
+	}
+	return r.globalLevel(), nil
+}
+
+func (r *InMemorySchemaRegistry) SetCompatibility(subject string, level CompatibilityLevel) (CompatibilityLevel, error) {
+	return r.SetCompatibilityContext(context.Background(), subject, level)
+}
+
+func (r *InMemorySchemaRegistry) SetCompatibilityContext(ctx context.Context, subject string, level CompatibilityLevel) (CompatibilityLevel, error) {
+	if !level.Valid() {
+		return "", &RegistryError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: ErrorCodeInvalidCompatibilityLevel, Message: fmt.Sprintf("Invalid compatibility level: %s", level)}
+	}
+	r.Lock()
+	defer r.Unlock()
+	if subject == "" {
+		r.Compatibility = level
+		return level, nil
+	}
+	r.ensureSubject(subject).compatibility = level
+	return level, nil
+}
+
+func (r *InMemorySchemaRegistry) ensureSubject(name string) *memorySubject {
+	s := r.lookupSubject(name)
+	r.storeSubject(name, s)
+	return s
+}
+
+func (r *InMemorySchemaRegistry) lookupSubject(name string) *memorySubject {
+	if s, hit := r.subjects[name]; hit {
+		return s
+	}
+	return &memorySubject{}
+}
+
+func (r *InMemorySchemaRegistry) storeSubject(name string, s *memorySubject) {
+	if r.subjects == nil {
+		r.subjects = map[string]*memorySubject{}
+	}
+	r.subjects[name] = s
+}
+
+func (r *InMemorySchemaRegistry) storeSchema(id uint32, schema *Schema) {
+	if r.schemas == nil {
+		r.schemas = map[uint32]*Schema{}
+		r.ids = map[string]uint32{}
+	}
+	r.schemas[id] = schema
+	if _, hit := r.ids[schema.String()]; !hit {
+		r.ids[schema.String()] = id
+	}
+	if id > r.maxID {
+		r.maxID = id
+	}
+}
+
+func (r *InMemorySchemaRegistry) subject(name string) (*memorySubject, error) {
+	s, hit := r.subjects[name]
+	if !hit || len(s.live()) == 0 {
+		return nil, subjectNotFound(name)
+	}
+	return s, nil
+}
+
+func (r *InMemorySchemaRegistry) findVersion(s *memorySubject, schema *Schema) *memoryVersion {
+	for _, v := range s.live() {
+		if r.schemas[v.id].String() == schema.String() {
+			return v
+		}
+	}
+	return nil
+}
+
+func (r *InMemorySchemaRegistry) globalLevel() CompatibilityLevel {
+	if r.Compatibility == "" {
+		return CompatibilityNone
+	}
+	return r.Compatibility
+}
+
+func (r *InMemorySchemaRegistry) globalMode() RegistryMode {
+	if r.Mode == "" {
+		return ModeReadWrite
+	}
+	return r.Mode
+}
+
+func (r *InMemorySchemaRegistry) mode(s *memorySubject) RegistryMode {
+	if s.mode != "" {
+		return s.mode
+	}
+	return r.globalMode()
+}
+
+func (r *InMemorySchemaRegistry) level(s *memorySubject) CompatibilityLevel {
+	if s.compatibility != "" {
+		return s.compatibility
+	}
+	return r.globalLevel()
+}
+
+func (r *InMemorySchemaRegistry) registeredSchema(subject string, v *memoryVersion) *RegisteredSchema {
+	return &RegisteredSchema{Subject: subject, Version: v.version, ID: v.id, Schema: r.schemas[v.id]}
+}
+
+func (s *memorySubject) live() []*memoryVersion {
+	var versions []*memoryVersion
+	for _, v := range s.versions {
+		if !v.deleted {
+			versions = append(versions, v)
+		}
+	}
+	return versions
+}
+
+func (s *memorySubject) nextVersion() int {
+	if len(s.versions) == 0 {
+		return 1
+	}
+	return s.versions[len(s.versions)-1].version + 1
+}
+
+func (s *memorySubject) version(version int, includeDeleted bool) (*memoryVersion, error) {
+	candidates := s.live()
+	if includeDeleted {
+		candidates = s.versions
+	}
+	if version == LatestVersion {
+		if len(candidates) == 0 {
+			return nil, &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeVersionNotFound, Message: "Version not found"}
+		}
+		return candidates[len(candidates)-1], nil
+	}
+	if version <= 0 {
+		return nil, &RegistryError{StatusCode: http.StatusUnprocessableEntity, ErrorCode: ErrorCodeInvalidVersion, Message: fmt.Sprintf("The specified version '%d' is not a valid version id", version)}
+	}
+	for _, v := range candidates {
+		if v.version == version {
+			return v, nil
+		}
+	}
+	return nil, &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeVersionNotFound, Message: "Version " + strconv.Itoa(version) + " not found"}
+}
+
+func subjectNotFound(subject string) *RegistryError {
+	return &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeSubjectNotFound, Message: fmt.Sprintf("Subject '%s' not found.", subject)}
+}
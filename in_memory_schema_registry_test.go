@@ -0,0 +1,124 @@
+package avroturf_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func TestInMemorySchemaRegistry(t *testing.T) {
+	r := avroturf.NewInMemorySchemaRegistry()
+	v1 := mustParse(t, `{"type":"record","name":"Rec","fields":[{"name":"a","type":"string"}]}`)
+	v2 := mustParse(t, `{"type":"record","name":"Rec","fields":[{"name":"b","type":"long"}]}`)
+
+	for i, subject := range []string{"subject1", "subject2", "subject1"} {
+		id, err := r.Register(subject, v1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 1 {
+			t.Errorf("registration %d: expected id 1 but got %d", i, id)
+		}
+	}
+	id, err := r.Register("subject1", v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 2 {
+		t.Errorf("expected id 2 but got %d", id)
+	}
+	versions, err := r.ListVersions("subject1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{1, 2}; !reflect.DeepEqual(expected, versions) {
+		t.Errorf("expected %v but got %v", expected, versions)
+	}
+	latest, err := r.FetchSchemaBySubjectVersion("subject1", avroturf.LatestVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Version != 2 || latest.ID != 2 || latest.Schema != v2 {
+		t.Errorf("unexpected latest: %+v", latest)
+	}
+
+	if _, err := r.SetCompatibility("subject2", avroturf.CompatibilityBackward); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Register("subject2", v2); !avroturf.IsIncompatibleSchema(err) {
+		t.Errorf("expected incompatible schema error but got %v", err)
+	}
+	if _, err := r.FetchSchema(3); !avroturf.IsSchemaNotFound(err) {
+		t.Errorf("expected schema not found but got %v", err)
+	}
+	if _, err := r.SetCompatibility("", "SOMETIMES"); err == nil {
+		t.Error("expected invalid compatibility level error")
+	}
+}
+
+func TestInMemorySchemaRegistryFailedRegisterLeavesNoSubject(t *testing.T) {
+	r := avroturf.NewInMemorySchemaRegistry()
+	v1 := mustParse(t, `{"type":"record","name":"Rec","fields":[{"name":"a","type":"string"}]}`)
+	if _, err := r.SetMode("", avroturf.ModeReadOnly); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Register("subject1", v1); err == nil {
+		t.Fatal("expected read-only mode to reject the registration")
+	}
+	if _, err := r.RegisterWithID("subject1", v1, 10, 1); err == nil {
+		t.Fatal("expected read-only mode to reject the import")
+	}
+	if _, err := r.GetMode("subject1"); !avroturf.IsSubjectNotFound(err) {
+		t.Errorf("expected subject not found but got %v", err)
+	}
+}
+
+func TestInMemorySchemaRegistrySubjectCompatibility(t *testing.T) {
+	r := &avroturf.InMemorySchemaRegistry{Compatibility: avroturf.CompatibilityBackward}
+	if _, err := r.SetCompatibility("foo-value", avroturf.CompatibilityFull); err != nil {
+		t.Fatal(err)
+	}
+	if level, err := r.GetCompatibility("foo-value"); err != nil || level != avroturf.CompatibilityFull {
+		t.Errorf("expected FULL but got %q (%v)", level, err)
+	}
+	if level, err := r.GetSubjectCompatibility("foo-value"); err != nil || level != avroturf.CompatibilityFull {
+		t.Errorf("expected FULL but got %q (%v)", level, err)
+	}
+	if level, err := r.GetCompatibility("bar-value"); err != nil || level != avroturf.CompatibilityBackward {
+		t.Errorf("expected the global level BACKWARD but got %q (%v)", level, err)
+	}
+	var registryErr *avroturf.RegistryError
+	if _, err := r.GetSubjectCompatibility("bar-value"); !errors.As(err, &registryErr) || registryErr.ErrorCode != avroturf.ErrorCodeSubjectLevelNotConfigured {
+		t.Errorf("expected subject level not configured but got %v", err)
+	}
+}
+
+func TestInMemorySchemaRegistryWithMessaging(t *testing.T) {
+	registry := avroturf.NewInMemorySchemaRegistry()
+	messaging := avroturf.NewMessagingWithRegistry("test-namespace", "testdata/", registry)
+	if err := registry.RegisterSchemaStore(messaging.SchemaStore); err != nil {
+		t.Fatal(err)
+	}
+	subjects, err := registry.ListSubjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"TestSchemaRoot"}; !reflect.DeepEqual(expected, subjects) {
+		t.Errorf("expected %v but got %v", expected, subjects)
+	}
+
+	messaging.EncodeMode = avroturf.EncodeModeLookup
+	data, err := messaging.Encode(record{Str: "hoge"}, "TestSchemaRoot", "test-name", "test-namespace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := record{}
+	if err := messaging.Decode(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Str != "hoge" {
+		t.Errorf("expected hoge but got %s", decoded.Str)
+	}
+}
@@ -1,36 +1,88 @@
 package avroturf
 
 import (
+	"context"
 	"encoding/binary"
 	"fmt"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/hamba/avro"
 )
 
 type Messaging struct {
-	sync.Mutex
+	sync.RWMutex
 	NameSpace   string
 	SchemaStore *SchemaStore
 	Registry    SchemaRegistry
 	SchemasByID map[uint32]*Schema
+
+	SubjectNameStrategy SubjectNameStrategy
+	EncodeMode          EncodeMode
+	Hooks               Hooks
+
+	fetches flightGroup
+}
+
+type EncodeMode int
+
+type Marshaler interface {
+	MarshalAvro(schema avro.Schema) ([]byte, error)
+}
+
+type Unmarshaler interface {
+	UnmarshalAvro(schema avro.Schema, data []byte) error
+}
+
+const (
+	EncodeModeRegister EncodeMode = iota
+	EncodeModeLookup
+)
+
+// Deprecated: NewMessaging panics when the options conflict; use NewMessagingWithConfluent instead.
+func NewMessaging(namespace string, path string, registryURL string, opts ...RegistryOption) *Messaging {
+	m, err := NewMessagingWithConfluent(namespace, path, registryURL, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return m
 }
 
-func NewMessaging(namespace string, path string, registryURL string) *Messaging {
+func NewMessagingWithConfluent(namespace string, path string, registryURL string, opts ...RegistryOption) (*Messaging, error) {
+	upstream, err := NewConfluentSchemaRegistry(registryURL, opts...)
+	if err != nil {
+		return nil, err
+	}
+	cache := NewInMemoryCache()
+	cache.Hooks = upstream.Hooks
 	return &Messaging{
 		NameSpace:   namespace,
 		SchemaStore: NewSchemaStore(path),
 		Registry: &CachedConfluentSchemaRegistry{
-			Upstream: &ConfluentSchemaRegistry{
-				RegistryURL: registryURL,
-			},
-			Cache: NewInMemoryCache(),
+			Upstream: upstream,
+			Cache:    cache,
+			Hooks:    upstream.Hooks,
 		},
 		SchemasByID: make(map[uint32]*Schema),
+		Hooks:       upstream.Hooks,
+	}, nil
+}
+
+func NewMessagingWithRegistry(namespace string, path string, registry SchemaRegistry) *Messaging {
+	return &Messaging{
+		NameSpace:   namespace,
+		SchemaStore: NewSchemaStore(path),
+		Registry:    registry,
+		SchemasByID: make(map[uint32]*Schema),
 	}
 }
 
 func (m *Messaging) GetSchema(data []byte) (*Schema, error) {
+	return m.GetSchemaContext(context.Background(), data)
+}
+
+func (m *Messaging) GetSchemaContext(ctx context.Context, data []byte) (*Schema, error) {
 	if len(data) < 5 {
 		return nil, fmt.Errorf("data too short: %d byte(s)", len(data))
 	}
@@ -40,41 +92,134 @@ func (m *Messaging) GetSchema(data []byte) (*Schema, error) {
 	}
 
 	schemaID := binary.BigEndian.Uint32(data[1:5])
+	m.RLock()
 	schema, hit := m.SchemasByID[schemaID]
-	if !hit {
+	m.RUnlock()
+	if hit {
+		return schema, nil
+	}
+	v, err := m.fetches.do(ctx, strconv.FormatUint(uint64(schemaID), 10), func() (interface{}, error) {
+		s, err := fetchSchemaContext(ctx, m.Registry, schemaID)
+		if err != nil {
+			return nil, err
+		}
 		m.Lock()
-		defer m.Unlock()
-		schema, hit = m.SchemasByID[schemaID]
-		if !hit {
-			s, err := m.Registry.FetchSchema(schemaID)
-			if err != nil {
-				return nil, err
-			}
-			schema = s
-			m.SchemasByID[schemaID] = s
+		if m.SchemasByID == nil {
+			m.SchemasByID = map[uint32]*Schema{}
 		}
+		m.SchemasByID[schemaID] = s
+		m.Unlock()
+		return s, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return schema, nil
+	return v.(*Schema), nil
 }
 
 func (m *Messaging) Decode(data []byte, obj interface{}) error {
-	writersSchema, err := m.GetSchema(data)
+	return m.DecodeContext(context.Background(), data, obj)
+}
+
+func (m *Messaging) DecodeContext(ctx context.Context, data []byte, obj interface{}) error {
+	started := time.Now()
+	writersSchema, err := m.GetSchemaContext(ctx, data)
 	if err != nil {
-		return err
+		return m.decoded(data, started, err)
+	}
+	return m.decoded(data, started, unmarshal(writersSchema.Schema, data[5:], obj))
+}
+
+func (m *Messaging) DecodeGeneric(data []byte) (interface{}, *Schema, error) {
+	return m.DecodeGenericContext(context.Background(), data)
+}
+
+func (m *Messaging) DecodeGenericContext(ctx context.Context, data []byte) (interface{}, *Schema, error) {
+	started := time.Now()
+	writersSchema, err := m.GetSchemaContext(ctx, data)
+	if err != nil {
+		return nil, nil, m.decoded(data, started, err)
+	}
+	v, err := readDatum(avro.NewReader(nil, 0).Reset(data[5:]), writersSchema.Schema)
+	if err != nil {
+		return nil, nil, m.decoded(data, started, err)
 	}
-	return avro.Unmarshal(writersSchema.Schema, data[5:], obj)
+	return v, writersSchema, m.decoded(data, started, nil)
+}
+
+func (m *Messaging) DecodeToJSON(data []byte) ([]byte, error) {
+	return m.DecodeToJSONContext(context.Background(), data)
+}
+
+func (m *Messaging) DecodeToJSONContext(ctx context.Context, data []byte) ([]byte, error) {
+	v, writersSchema, err := m.DecodeGenericContext(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalAvroJSON(writersSchema, v)
 }
 
 func (m *Messaging) DecodeByLocalSchema(data []byte, obj interface{}, schemaName string, namespace string) error {
+	started := time.Now()
 	localSchema, err := m.SchemaStore.Find(schemaName, namespace)
+	if err != nil {
+		return m.decoded(data, started, err)
+	}
+	return m.decoded(data, started, unmarshal(localSchema.Schema, data[5:], obj))
+}
+
+func (m *Messaging) DecodeWithReaderSchema(data []byte, obj interface{}, schemaName string, namespace string) error {
+	return m.DecodeWithReaderSchemaContext(context.Background(), data, obj, schemaName, namespace)
+}
+
+func (m *Messaging) DecodeWithReaderSchemaContext(ctx context.Context, data []byte, obj interface{}, schemaName string, namespace string) error {
+	started := time.Now()
+	return m.decoded(data, started, m.decodeWithReaderSchema(ctx, data, obj, schemaName, namespace))
+}
+
+func (m *Messaging) decodeWithReaderSchema(ctx context.Context, data []byte, obj interface{}, schemaName string, namespace string) error {
+	writersSchema, err := m.GetSchemaContext(ctx, data)
+	if err != nil {
+		return err
+	}
+	readersSchema, err := m.SchemaStore.Find(schemaName, namespace)
+	if err != nil {
+		return err
+	}
+	if writersSchema.String() == readersSchema.String() {
+		return unmarshal(readersSchema.Schema, data[5:], obj)
+	}
+	resolved, err := Resolve(writersSchema, readersSchema, data[5:])
 	if err != nil {
 		return err
 	}
-	return avro.Unmarshal(localSchema.Schema, data[5:], obj)
+	return unmarshal(readersSchema.Schema, resolved, obj)
+}
+
+func (m *Messaging) decoded(data []byte, started time.Time, err error) error {
+	if err != nil {
+		emit(m.Hooks, Event{Type: EventDecodeFailed, SchemaID: schemaIDOf(data), Duration: time.Since(started), Err: err})
+	} else {
+		emit(m.Hooks, Event{Type: EventDecoded, SchemaID: schemaIDOf(data), Duration: time.Since(started)})
+	}
+	return err
+}
+
+func (m *Messaging) encoded(subject string, started time.Time, data []byte, err error) ([]byte, error) {
+	if err != nil {
+		emit(m.Hooks, Event{Type: EventEncodeFailed, Subject: subject, Duration: time.Since(started), Err: err})
+		return nil, err
+	}
+	emit(m.Hooks, Event{Type: EventEncoded, Subject: subject, SchemaID: schemaIDOf(data), Duration: time.Since(started)})
+	return data, nil
 }
 
 func (m *Messaging) GetRecordSchema(data []byte) (*avro.RecordSchema, error) {
-	schema, err := m.GetSchema(data)
+	return m.GetRecordSchemaContext(context.Background(), data)
+}
+
+func (m *Messaging) GetRecordSchemaContext(ctx context.Context, data []byte) (*avro.RecordSchema, error) {
+	schema, err := m.GetSchemaContext(ctx, data)
 	if err != nil {
 		return nil, err
 	}
@@ -86,23 +231,108 @@ func (m *Messaging) GetRecordSchema(data []byte) (*avro.RecordSchema, error) {
 }
 
 func (m *Messaging) Encode(obj interface{}, subject string, schemaName string, namespace string) ([]byte, error) {
-	schemaID, schema, err := m.RegisterSchema(subject, schemaName, namespace)
+	return m.EncodeContext(context.Background(), obj, subject, schemaName, namespace)
+}
+
+func (m *Messaging) EncodeContext(ctx context.Context, obj interface{}, subject string, schemaName string, namespace string) ([]byte, error) {
+	started := time.Now()
+	data, err := m.encode(ctx, obj, subject, schemaName, namespace)
+	return m.encoded(subject, started, data, err)
+}
+
+func (m *Messaging) encode(ctx context.Context, obj interface{}, subject string, schemaName string, namespace string) ([]byte, error) {
+	schemaID, schema, err := m.RegisterSchemaContext(ctx, subject, schemaName, namespace)
 	if err != nil {
 		return nil, err
 	}
 	return EncodeBySchemaAndId(obj, schemaID, schema)
 }
 
-func (m *Messaging) EncodeByLocalSchema(obj interface{}, schemaName string, namespace string, schemaID uint32) ([]byte, error) {
+func (m *Messaging) EncodeJSON(jsonBytes []byte, subject string, schemaName string, namespace string) ([]byte, error) {
+	return m.EncodeJSONContext(context.Background(), jsonBytes, subject, schemaName, namespace)
+}
+
+func (m *Messaging) EncodeJSONContext(ctx context.Context, jsonBytes []byte, subject string, schemaName string, namespace string) ([]byte, error) {
+	started := time.Now()
+	data, err := m.encodeJSON(ctx, jsonBytes, subject, schemaName, namespace)
+	return m.encoded(subject, started, data, err)
+}
+
+func (m *Messaging) encodeJSON(ctx context.Context, jsonBytes []byte, subject string, schemaName string, namespace string) ([]byte, error) {
 	schema, err := m.SchemaStore.Find(schemaName, namespace)
 	if err != nil {
 		return nil, err
 	}
-	return EncodeBySchemaAndId(obj, schemaID, schema)
+	v, err := UnmarshalAvroJSON(schema, jsonBytes)
+	if err != nil {
+		return nil, err
+	}
+	schemaID, _, err := m.RegisterSchemaContext(ctx, subject, schemaName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeGenericBySchemaAndId(v, schemaID, schema)
+}
+
+func (m *Messaging) EncodeForTopic(obj interface{}, topic string, isKey bool, schemaName string, namespace string) ([]byte, error) {
+	return m.EncodeForTopicContext(context.Background(), obj, topic, isKey, schemaName, namespace)
+}
+
+func (m *Messaging) EncodeForTopicContext(ctx context.Context, obj interface{}, topic string, isKey bool, schemaName string, namespace string) ([]byte, error) {
+	started := time.Now()
+	schema, err := m.SchemaStore.Find(schemaName, namespace)
+	if err != nil {
+		return m.encoded("", started, nil, err)
+	}
+	subject, err := m.subjectNameStrategy().SubjectName(topic, isKey, schema)
+	if err != nil {
+		return m.encoded("", started, nil, err)
+	}
+	schemaID, err := m.registerSchema(ctx, subject, schema)
+	if err != nil {
+		return m.encoded(subject, started, nil, err)
+	}
+	data, err := EncodeBySchemaAndId(obj, schemaID, schema)
+	return m.encoded(subject, started, data, err)
+}
+
+func (m *Messaging) subjectNameStrategy() SubjectNameStrategy {
+	if m.SubjectNameStrategy == nil {
+		return TopicNameStrategy{}
+	}
+	return m.SubjectNameStrategy
+}
+
+func (m *Messaging) EncodeWithVersion(obj interface{}, subject string, version int) ([]byte, error) {
+	return m.EncodeWithVersionContext(context.Background(), obj, subject, version)
+}
+
+func (m *Messaging) EncodeWithVersionContext(ctx context.Context, obj interface{}, subject string, version int) ([]byte, error) {
+	started := time.Now()
+	registry, err := m.subjectRegistry()
+	if err != nil {
+		return m.encoded(subject, started, nil, err)
+	}
+	registered, err := registry.FetchSchemaBySubjectVersionContext(ctx, subject, version)
+	if err != nil {
+		return m.encoded(subject, started, nil, err)
+	}
+	data, err := EncodeBySchemaAndId(obj, registered.ID, registered.Schema)
+	return m.encoded(subject, started, data, err)
+}
+
+func (m *Messaging) EncodeByLocalSchema(obj interface{}, schemaName string, namespace string, schemaID uint32) ([]byte, error) {
+	started := time.Now()
+	schema, err := m.SchemaStore.Find(schemaName, namespace)
+	if err != nil {
+		return m.encoded("", started, nil, err)
+	}
+	data, err := EncodeBySchemaAndId(obj, schemaID, schema)
+	return m.encoded("", started, data, err)
 }
 
 func EncodeBySchemaAndId(obj interface{}, schemaID uint32, schema *Schema) ([]byte, error) {
-	data, err := avro.Marshal(schema.Schema, obj)
+	data, err := marshal(schema.Schema, obj)
 	if err != nil || len(data) == 0 {
 		return nil, err
 	}
@@ -111,20 +341,97 @@ func EncodeBySchemaAndId(obj interface{}, schemaID uint32, schema *Schema) ([]by
 	return data, nil
 }
 
+func marshal(schema avro.Schema, obj interface{}) ([]byte, error) {
+	if m, ok := obj.(Marshaler); ok {
+		return m.MarshalAvro(schema)
+	}
+	return avro.Marshal(schema, obj)
+}
+
+func unmarshal(schema avro.Schema, data []byte, obj interface{}) error {
+	if u, ok := obj.(Unmarshaler); ok {
+		return u.UnmarshalAvro(schema, data)
+	}
+	return avro.Unmarshal(schema, data, obj)
+}
+
+func EncodeGenericBySchemaAndId(v interface{}, schemaID uint32, schema *Schema) ([]byte, error) {
+	w := avro.NewWriter(nil, 512)
+	w.Write([]byte{0, 0, 0, 0, 0})
+	if err := writeDatum(w, schema.Schema, v); err != nil {
+		return nil, err
+	}
+	data := w.Buffer()
+	binary.BigEndian.PutUint32(data[1:5], schemaID)
+	return data, nil
+}
+
+func MarshalGeneric(schema avro.Schema, v interface{}) ([]byte, error) {
+	w := avro.NewWriter(nil, 512)
+	if err := writeDatum(w, schema, v); err != nil {
+		return nil, err
+	}
+	return w.Buffer(), nil
+}
+
+func UnmarshalGeneric(schema avro.Schema, data []byte) (interface{}, error) {
+	return readDatum(avro.NewReader(nil, 0).Reset(data), schema)
+}
+
 func (m *Messaging) RegisterSchema(subject string, schemaName string, namespace string) (uint32, *Schema, error) {
+	return m.RegisterSchemaContext(context.Background(), subject, schemaName, namespace)
+}
+
+func (m *Messaging) RegisterSchemaContext(ctx context.Context, subject string, schemaName string, namespace string) (uint32, *Schema, error) {
 	schema, err := m.SchemaStore.Find(schemaName, namespace)
 	if err != nil {
 		return 0, nil, err
 	}
 	if subject == "" {
-		s, ok := schema.Schema.(avro.NamedSchema)
-		if ok {
-			subject = s.FullName()
+		subject, err = m.defaultSubject(schema)
+		if err != nil {
+			return 0, nil, err
 		}
 	}
-	schemaID, err := m.Registry.Register(subject, schema)
+	schemaID, err := m.registerSchema(ctx, subject, schema)
 	if err != nil {
 		return 0, nil, err
 	}
 	return schemaID, schema, nil
 }
+
+func (m *Messaging) defaultSubject(schema *Schema) (string, error) {
+	if m.SubjectNameStrategy != nil {
+		if subject, err := m.SubjectNameStrategy.SubjectName("", false, schema); err == nil {
+			return subject, nil
+		}
+	}
+	s, ok := schema.Schema.(avro.NamedSchema)
+	if !ok {
+		return "", nil
+	}
+	return s.FullName(), nil
+}
+
+func (m *Messaging) registerSchema(ctx context.Context, subject string, schema *Schema) (uint32, error) {
+	if m.EncodeMode != EncodeModeLookup {
+		return registerContext(ctx, m.Registry, subject, schema)
+	}
+	registry, err := m.subjectRegistry()
+	if err != nil {
+		return 0, err
+	}
+	registered, err := registry.LookupSchemaContext(ctx, subject, schema)
+	if err != nil {
+		return 0, err
+	}
+	return registered.ID, nil
+}
+
+func (m *Messaging) subjectRegistry() (SubjectRegistry, error) {
+	registry, ok := m.Registry.(SubjectRegistry)
+	if !ok {
+		return nil, fmt.Errorf("registry does not support subject lookups: %T", m.Registry)
+	}
+	return registry, nil
+}
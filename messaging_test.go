@@ -2,12 +2,19 @@ package avroturf_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/binary"
 	"os"
 	"path"
+	"reflect"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/hamba/avro"
 
 	"github.com/wanabe/avroturf-go"
 	"github.com/wanabe/avroturf-go/mock_avroturf"
@@ -17,6 +24,13 @@ type record struct {
 	Str string `avro:"str"`
 }
 
+type mockSubjectSchemaRegistry struct {
+	*mock_avroturf.MockContextSchemaRegistry
+	*mock_avroturf.MockSubjectRegistry
+}
+
+type contextKey struct{}
+
 func TestNewMessaging(t *testing.T) {
 	messaging := avroturf.NewMessaging(
 		"com.example",
@@ -28,8 +42,9 @@ func TestNewMessaging(t *testing.T) {
 		t.Errorf("unexpected namespace: %s", messaging.NameSpace)
 	}
 	registry := messaging.Registry.(*avroturf.CachedConfluentSchemaRegistry)
-	if registry.Upstream.RegistryURL != "http://example.com" {
-		t.Errorf("unexpected registry url: %s", registry.Upstream.RegistryURL)
+	upstream := registry.Upstream.(*avroturf.ConfluentSchemaRegistry)
+	if upstream.RegistryURL != "http://example.com" {
+		t.Errorf("unexpected registry url: %s", upstream.RegistryURL)
 	}
 	store := messaging.SchemaStore
 	if store.Path != "./" {
@@ -37,6 +52,19 @@ func TestNewMessaging(t *testing.T) {
 	}
 }
 
+func TestNewMessagingWithConfluentRejectsConflictingOptions(t *testing.T) {
+	_, err := avroturf.NewMessagingWithConfluent(
+		"com.example",
+		"./",
+		"http://example.com",
+		avroturf.WithBasicAuth("key:secret"),
+		avroturf.WithTokenSource(avroturf.StaticTokenSource("token")),
+	)
+	if expected := "basic auth and a token source cannot be used together"; err == nil || err.Error() != expected {
+		t.Errorf("expected '%s' but got %v", expected, err)
+	}
+}
+
 func TestDecode(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -75,6 +103,112 @@ func TestDecode(t *testing.T) {
 	}
 }
 
+func TestDecodeContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	registry := mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	registry.EXPECT().FetchSchemaContext(ctx, uint32(123)).Return(nil, ctx.Err())
+
+	messaging := &avroturf.Messaging{Registry: registry, NameSpace: "test-namespace", SchemasByID: make(map[uint32]*avroturf.Schema)}
+	obj := record{}
+	b := []byte{0, 0, 0, 0, 123, 8}
+	b = append(b, "hoge"...)
+
+	err := messaging.DecodeContext(ctx, b, &obj)
+	if err != context.Canceled {
+		t.Errorf("expected %v but got %v", context.Canceled, err)
+	}
+}
+
+func TestDecodeContextWithContextRegistry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	schema, err := avroturf.Parse(`{"type":"record","name":"TestSchemaRoot","fields":[{"type":"string","name":"str"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), contextKey{}, "decode")
+	registry := mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	registry.EXPECT().FetchSchemaContext(ctx, uint32(123)).Return(schema, nil)
+
+	messaging := &avroturf.Messaging{Registry: registry, NameSpace: "test-namespace", SchemasByID: make(map[uint32]*avroturf.Schema)}
+	obj := record{}
+	b := []byte{0, 0, 0, 0, 123, 8}
+	b = append(b, "hoge"...)
+
+	if err := messaging.DecodeContext(ctx, b, &obj); err != nil {
+		t.Fatal(err)
+	}
+	if obj.Str != "hoge" {
+		t.Errorf("expected \"%s\" but got \"%s\"", "hoge", obj.Str)
+	}
+}
+
+func TestDecodeGeneric(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	schema, err := avroturf.Parse(`
+		{
+			"type": "record",
+			"name": "TestSchemaRoot",
+			"fields": [
+				{
+					"type": "string",
+					"name": "str"
+				},
+				{
+					"type": ["null", "long"],
+					"name": "num"
+				}
+			]
+		}
+	`)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	registry := mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	registry.EXPECT().FetchSchemaContext(gomock.Any(), uint32(123)).Return(schema, nil)
+
+	messaging := &avroturf.Messaging{Registry: registry, NameSpace: "test-namespace", SchemasByID: make(map[uint32]*avroturf.Schema)}
+	b := []byte{0, 0, 0, 0, 123, 8}
+	b = append(b, "hoge"...)
+	b = append(b, 2, 14)
+
+	v, s, err := messaging.DecodeGeneric(b)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	if s != schema {
+		t.Errorf("expected %v but got %v", schema, s)
+	}
+	expected := map[string]interface{}{"str": "hoge", "num": map[string]interface{}{"long": int64(7)}}
+	if !reflect.DeepEqual(expected, v) {
+		t.Errorf("expected %#v but got %#v", expected, v)
+	}
+
+	j, err := messaging.DecodeToJSON(b)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	if expected := `{"str":"hoge","num":{"long":7}}`; string(j) != expected {
+		t.Errorf("expected %s but got %s", expected, j)
+	}
+
+	_, _, err = messaging.DecodeGeneric(b[:len(b)-1])
+	if err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
 func TestDecodeByLocalSchema(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -101,6 +235,56 @@ func TestDecodeByLocalSchema(t *testing.T) {
 	}
 }
 
+func TestDecodeWithReaderSchema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	schema, err := avroturf.Parse(`
+		{
+			"type": "record",
+			"name": "TestSchemaRoot",
+			"fields": [
+				{
+					"type": "string",
+					"name": "str"
+				}
+			]
+		}
+	`)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	registry := mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	registry.EXPECT().FetchSchemaContext(gomock.Any(), uint32(123)).Return(schema, nil)
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Error(err)
+	}
+	messaging := &avroturf.Messaging{
+		Registry:    registry,
+		NameSpace:   "test-namespace",
+		SchemasByID: make(map[uint32]*avroturf.Schema),
+		SchemaStore: avroturf.NewSchemaStore(path.Join(dir, "testdata")),
+	}
+	obj := struct {
+		Num int64  `avro:"num"`
+		Str string `avro:"str"`
+	}{}
+	b := []byte{0, 0, 0, 0, 123, 8}
+	b = append(b, "hoge"...)
+
+	err = messaging.DecodeWithReaderSchema(b, &obj, "test-reader", "test-namespace")
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	if obj.Str != "hoge" || obj.Num != 7 {
+		t.Errorf("expected {7 hoge} but got %+v", obj)
+	}
+}
+
 func TestFailDecode(t *testing.T) {
 	messaging := &avroturf.Messaging{}
 	obj := record{}
@@ -200,6 +384,199 @@ func TestGetSchemaIOnParallel(t *testing.T) {
 	wg.Wait()
 }
 
+type slowSchemaRegistry struct {
+	delay   time.Duration
+	blocked chan struct{}
+	calls   int32
+	schema  *avroturf.Schema
+	panics  bool
+}
+
+func (r *slowSchemaRegistry) FetchSchema(schemaID uint32) (*avroturf.Schema, error) {
+	return r.FetchSchemaContext(context.Background(), schemaID)
+}
+
+func (r *slowSchemaRegistry) FetchSchemaContext(ctx context.Context, schemaID uint32) (*avroturf.Schema, error) {
+	atomic.AddInt32(&r.calls, 1)
+	if r.panics {
+		panic("boom")
+	}
+	if schemaID == 1 && r.blocked != nil {
+		select {
+		case <-r.blocked:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	time.Sleep(r.delay)
+	return r.schema, nil
+}
+
+func (r *slowSchemaRegistry) Register(subject string, schema *avroturf.Schema) (uint32, error) {
+	return r.RegisterContext(context.Background(), subject, schema)
+}
+
+func (r *slowSchemaRegistry) RegisterContext(ctx context.Context, subject string, schema *avroturf.Schema) (uint32, error) {
+	atomic.AddInt32(&r.calls, 1)
+	time.Sleep(r.delay)
+	return 1, nil
+}
+
+func TestGetSchemaDeduplicatesFetches(t *testing.T) {
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := &slowSchemaRegistry{blocked: make(chan struct{}), schema: schema}
+	messaging := &avroturf.Messaging{Registry: registry, SchemasByID: make(map[uint32]*avroturf.Schema)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := messaging.GetSchema([]byte{0, 0, 0, 0, 1}); err != nil {
+				t.Errorf("unexpected err: %v", err)
+			}
+		}()
+	}
+
+	if _, err := messaging.GetSchema([]byte{0, 0, 0, 0, 2}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(registry.blocked)
+	wg.Wait()
+	if calls := atomic.LoadInt32(&registry.calls); calls != 2 {
+		t.Errorf("expected 2 registry calls but got %d", calls)
+	}
+}
+
+func TestGetSchemaSurvivesLeaderCancel(t *testing.T) {
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := &slowSchemaRegistry{blocked: make(chan struct{}), schema: schema}
+	messaging := &avroturf.Messaging{Registry: registry, SchemasByID: make(map[uint32]*avroturf.Schema)}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err := messaging.GetSchemaContext(ctx, []byte{0, 0, 0, 0, 1})
+		leaderErr <- err
+	}()
+	for atomic.LoadInt32(&registry.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	waiterErr := make(chan error)
+	go func() {
+		_, err := messaging.GetSchemaContext(context.Background(), []byte{0, 0, 0, 0, 1})
+		waiterErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	if err := <-leaderErr; err != context.Canceled {
+		t.Errorf("expected leader to be canceled but got %v", err)
+	}
+	close(registry.blocked)
+	if err := <-waiterErr; err != nil {
+		t.Errorf("expected waiter to succeed but got %v", err)
+	}
+	if calls := atomic.LoadInt32(&registry.calls); calls != 2 {
+		t.Errorf("expected 2 registry calls but got %d", calls)
+	}
+}
+
+func TestGetSchemaWaiterHonorsContext(t *testing.T) {
+	registry := &slowSchemaRegistry{blocked: make(chan struct{})}
+	defer close(registry.blocked)
+	messaging := &avroturf.Messaging{Registry: registry, SchemasByID: make(map[uint32]*avroturf.Schema)}
+
+	go messaging.GetSchema([]byte{0, 0, 0, 0, 1})
+	for atomic.LoadInt32(&registry.calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := messaging.GetSchemaContext(ctx, []byte{0, 0, 0, 0, 1}); err != context.DeadlineExceeded {
+		t.Errorf("expected deadline exceeded but got %v", err)
+	}
+}
+
+func TestGetSchemaRecoversPanic(t *testing.T) {
+	registry := &slowSchemaRegistry{panics: true}
+	messaging := &avroturf.Messaging{Registry: registry, SchemasByID: make(map[uint32]*avroturf.Schema)}
+	_, err := messaging.GetSchema([]byte{0, 0, 0, 0, 1})
+	if expected := "panic during fetch: boom"; err == nil || err.Error() != expected {
+		t.Errorf("expected '%s' but got %v", expected, err)
+	}
+}
+
+func TestDecodeOnParallel(t *testing.T) {
+	schema, err := avroturf.Parse(`
+		{
+			"type": "record",
+			"name": "TestSchemaRoot",
+			"fields": [
+				{
+					"type": "string",
+					"name": "str"
+				}
+			]
+		}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := &slowSchemaRegistry{schema: schema}
+	messaging := &avroturf.Messaging{Registry: registry}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 300; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				data := []byte{0, 0, 0, 0, byte((i + j) % 32), 8}
+				data = append(data, "hoge"...)
+				var r record
+				if err := messaging.Decode(data, &r); err != nil {
+					t.Errorf("unexpected err: %v", err)
+					return
+				}
+				if r.Str != "hoge" {
+					t.Errorf("expected hoge but got %s", r.Str)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if calls := atomic.LoadInt32(&registry.calls); calls < 32 {
+		t.Errorf("expected at least 32 registry calls but got %d", calls)
+	}
+}
+
+func BenchmarkGetSchemaParallel(b *testing.B) {
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	registry := &slowSchemaRegistry{delay: time.Millisecond, schema: schema}
+	messaging := &avroturf.Messaging{Registry: registry, SchemasByID: make(map[uint32]*avroturf.Schema)}
+	var n uint32
+	b.RunParallel(func(pb *testing.PB) {
+		data := []byte{0, 0, 0, 0, 0}
+		for pb.Next() {
+			binary.BigEndian.PutUint32(data[1:5], atomic.AddUint32(&n, 1)/64)
+			if _, err := messaging.GetSchema(data); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
 func TestGetRecordSchema(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -287,6 +664,319 @@ func TestEncode(t *testing.T) {
 	}
 }
 
+func TestEncodeContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	registry := mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	registry.EXPECT().RegisterContext(ctx, "TestSchemaRoot-input", gomock.Any()).Return(uint32(0), ctx.Err())
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Error(err)
+	}
+	messaging := &avroturf.Messaging{
+		Registry:    registry,
+		NameSpace:   "test-namespace",
+		SchemasByID: make(map[uint32]*avroturf.Schema),
+		SchemaStore: avroturf.NewSchemaStore(path.Join(dir, "testdata")),
+	}
+	obj := record{Str: "hoge"}
+
+	_, err = messaging.EncodeContext(ctx, &obj, "TestSchemaRoot-input", "test-name", "test-namespace")
+	if err != context.Canceled {
+		t.Errorf("expected %v but got %v", context.Canceled, err)
+	}
+}
+
+func TestEncodeContextWithContextRegistry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.WithValue(context.Background(), contextKey{}, "encode")
+	registry := mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	registry.EXPECT().RegisterContext(ctx, "TestSchemaRoot-input", gomock.Any()).Return(uint32(123), nil)
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Error(err)
+	}
+	messaging := &avroturf.Messaging{
+		Registry:    registry,
+		NameSpace:   "test-namespace",
+		SchemasByID: make(map[uint32]*avroturf.Schema),
+		SchemaStore: avroturf.NewSchemaStore(path.Join(dir, "testdata")),
+	}
+
+	b, err := messaging.EncodeContext(ctx, &record{Str: "hoge"}, "TestSchemaRoot-input", "test-name", "test-namespace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append([]byte{0, 0, 0, 0, 123, 8}, "hoge"...)
+	if !bytes.Equal(expected, b) {
+		t.Errorf("expected %+v but got %+v", expected, b)
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	registry := mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	registry.EXPECT().RegisterContext(gomock.Any(), "TestSchemaRoot-input", gomock.Any()).Return(uint32(123), nil)
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Error(err)
+	}
+	messaging := &avroturf.Messaging{
+		Registry:    registry,
+		NameSpace:   "test-namespace",
+		SchemasByID: make(map[uint32]*avroturf.Schema),
+		SchemaStore: avroturf.NewSchemaStore(path.Join(dir, "testdata")),
+	}
+
+	b, err := messaging.EncodeJSON([]byte(`{"str": "hoge"}`), "TestSchemaRoot-input", "test-name", "test-namespace")
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	expected := []byte{0, 0, 0, 0, 123, 8}
+	expected = append(expected, "hoge"...)
+	if bytes.Compare(expected, b) != 0 {
+		t.Errorf("expected %+v but got %+v", expected, b)
+	}
+
+	_, err = messaging.EncodeJSON([]byte(`{"str": 1}`), "TestSchemaRoot-input", "test-name", "test-namespace")
+	if err == nil {
+		t.Error("expected error but got nil")
+	}
+}
+
+func TestEncodeForTopic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	schema, err := avroturf.Parse(`
+		{
+			"type": "record",
+			"name": "TestSchemaRoot",
+			"fields": [
+				{
+					"type": "string",
+					"name": "str"
+				}
+			]
+		}
+	`)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	registry := mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	registry.EXPECT().RegisterContext(gomock.Any(), "test-topic-value", schema).Return(uint32(123), nil)
+	registry.EXPECT().RegisterContext(gomock.Any(), "test-topic-TestSchemaRoot", gomock.Any()).Return(uint32(124), nil)
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Error(err)
+	}
+	messaging := &avroturf.Messaging{
+		Registry:    registry,
+		NameSpace:   "test-namespace",
+		SchemasByID: make(map[uint32]*avroturf.Schema),
+		SchemaStore: avroturf.NewSchemaStore(path.Join(dir, "testdata")),
+	}
+	obj := record{Str: "hoge"}
+
+	b, err := messaging.EncodeForTopic(&obj, "test-topic", false, "test-name", "test-namespace")
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	expected := []byte{0, 0, 0, 0, 123, 8}
+	expected = append(expected, "hoge"...)
+	if bytes.Compare(expected, b) != 0 {
+		t.Errorf("expected %+v but got %+v", expected, b)
+	}
+
+	messaging.SubjectNameStrategy = avroturf.TopicRecordNameStrategy{}
+	b, err = messaging.EncodeForTopic(&obj, "test-topic", false, "test-name", "test-namespace")
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	expected[4] = 124
+	if bytes.Compare(expected, b) != 0 {
+		t.Errorf("expected %+v but got %+v", expected, b)
+	}
+}
+
+type prefixNameStrategy string
+
+func (p prefixNameStrategy) SubjectName(topic string, isKey bool, schema *avroturf.Schema) (string, error) {
+	name, err := avroturf.RecordNameStrategy{}.SubjectName(topic, isKey, schema)
+	return string(p) + name, err
+}
+
+func TestEncodeWithoutSubjectUsesStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	registry := mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	registry.EXPECT().RegisterContext(gomock.Any(), "TestSchemaRoot", gomock.Any()).Return(uint32(123), nil).Times(2)
+	registry.EXPECT().RegisterContext(gomock.Any(), "team.TestSchemaRoot", gomock.Any()).Return(uint32(124), nil)
+
+	messaging := avroturf.NewMessagingWithRegistry("test-namespace", "testdata", registry)
+	if _, err := messaging.Encode(record{Str: "hoge"}, "", "test-name", "test-namespace"); err != nil {
+		t.Fatal(err)
+	}
+	messaging.SubjectNameStrategy = prefixNameStrategy("team.")
+	b, err := messaging.Encode(record{Str: "hoge"}, "", "test-name", "test-namespace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b[4] != 124 {
+		t.Errorf("expected schema id 124 but got %v", b)
+	}
+
+	messaging.SubjectNameStrategy = avroturf.TopicNameStrategy{}
+	b, err = messaging.Encode(record{Str: "hoge"}, "", "test-name", "test-namespace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b[4] != 123 {
+		t.Errorf("expected a strategy that needs a topic to keep the record name subject but got %v", b)
+	}
+}
+
+func TestEncodeLookupMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	schema, err := avroturf.Parse(`
+		{
+			"type": "record",
+			"name": "TestSchemaRoot",
+			"fields": [
+				{
+					"type": "string",
+					"name": "str"
+				}
+			]
+		}
+	`)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	subjectRegistry := mock_avroturf.NewMockSubjectRegistry(ctrl)
+	subjectRegistry.EXPECT().LookupSchemaContext(gomock.Any(), "TestSchemaRoot-input", schema).Return(&avroturf.RegisteredSchema{ID: 123}, nil)
+
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Error(err)
+	}
+	messaging := &avroturf.Messaging{
+		Registry:    &mockSubjectSchemaRegistry{mock_avroturf.NewMockContextSchemaRegistry(ctrl), subjectRegistry},
+		NameSpace:   "test-namespace",
+		SchemasByID: make(map[uint32]*avroturf.Schema),
+		SchemaStore: avroturf.NewSchemaStore(path.Join(dir, "testdata")),
+		EncodeMode:  avroturf.EncodeModeLookup,
+	}
+	obj := record{Str: "hoge"}
+
+	b, err := messaging.Encode(&obj, "TestSchemaRoot-input", "test-name", "test-namespace")
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	expected := []byte{0, 0, 0, 0, 123, 8}
+	expected = append(expected, "hoge"...)
+	if bytes.Compare(expected, b) != 0 {
+		t.Errorf("expected %+v but got %+v", expected, b)
+	}
+
+	messaging.Registry = mock_avroturf.NewMockContextSchemaRegistry(ctrl)
+	_, err = messaging.Encode(&obj, "TestSchemaRoot-input", "test-name", "test-namespace")
+	if err == nil || err.Error() != "registry does not support subject lookups: *mock_avroturf.MockContextSchemaRegistry" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestMessagingWithoutContextRegistry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	messaging := avroturf.NewMessagingWithRegistry("test-namespace", "testdata", nil)
+	schema, err := messaging.SchemaStore.Find("test-name", "test-namespace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := mock_avroturf.NewMockSchemaRegistry(ctrl)
+	registry.EXPECT().Register("test-value", schema).Return(uint32(123), nil)
+	registry.EXPECT().FetchSchema(uint32(123)).Return(schema, nil)
+	messaging.Registry = avroturf.NewCachedSchemaRegistry(registry, avroturf.NewInMemoryCache())
+
+	b, err := messaging.EncodeContext(context.Background(), record{Str: "hoge"}, "test-value", "test-name", "test-namespace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	messaging.Registry = registry
+	decoded := record{}
+	if err := messaging.DecodeContext(context.Background(), b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Str != "hoge" {
+		t.Errorf("expected hoge but got %s", decoded.Str)
+	}
+}
+
+func TestEncodeWithVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	schema, err := avroturf.Parse(`
+		{
+			"type": "record",
+			"name": "RegisteredSchema",
+			"fields": [
+				{
+					"type": "string",
+					"name": "str"
+				}
+			]
+		}
+	`)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+	}
+
+	subjectRegistry := mock_avroturf.NewMockSubjectRegistry(ctrl)
+	subjectRegistry.EXPECT().FetchSchemaBySubjectVersionContext(gomock.Any(), "TestSchemaRoot-input", 2).Return(&avroturf.RegisteredSchema{ID: 123, Version: 2, Schema: schema}, nil)
+
+	messaging := &avroturf.Messaging{
+		Registry:    &mockSubjectSchemaRegistry{mock_avroturf.NewMockContextSchemaRegistry(ctrl), subjectRegistry},
+		NameSpace:   "test-namespace",
+		SchemasByID: make(map[uint32]*avroturf.Schema),
+	}
+	obj := record{Str: "hoge"}
+
+	b, err := messaging.EncodeWithVersion(&obj, "TestSchemaRoot-input", 2)
+	if err != nil {
+		t.Errorf("unexpected err: %v", err)
+		return
+	}
+	expected := []byte{0, 0, 0, 0, 123, 8}
+	expected = append(expected, "hoge"...)
+	if bytes.Compare(expected, b) != 0 {
+		t.Errorf("expected %+v but got %+v", expected, b)
+	}
+}
+
 func TestEncodeByLocalSchema(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -313,3 +1003,42 @@ func TestEncodeByLocalSchema(t *testing.T) {
 		t.Errorf("expected %+v but got %+v", expected, b)
 	}
 }
+
+type upperRecord struct {
+	Str string
+}
+
+func (r upperRecord) MarshalAvro(schema avro.Schema) ([]byte, error) {
+	return avro.Marshal(schema, record{Str: strings.ToUpper(r.Str)})
+}
+
+func (r *upperRecord) UnmarshalAvro(schema avro.Schema, data []byte) error {
+	var obj record
+	if err := avro.Unmarshal(schema, data, &obj); err != nil {
+		return err
+	}
+	r.Str = strings.ToLower(obj.Str)
+	return nil
+}
+
+func TestEncodeAndDecodeWithMarshaler(t *testing.T) {
+	messaging := &avroturf.Messaging{
+		NameSpace:   "test-namespace",
+		SchemaStore: avroturf.NewSchemaStore("testdata/"),
+	}
+	b, err := messaging.EncodeByLocalSchema(upperRecord{Str: "hoge"}, "test-name", "test-namespace", 123)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append([]byte{0, 0, 0, 0, 123, 8}, "HOGE"...)
+	if !bytes.Equal(expected, b) {
+		t.Errorf("expected %+v but got %+v", expected, b)
+	}
+	obj := upperRecord{}
+	if err := messaging.DecodeByLocalSchema(b, &obj, "test-name", "test-namespace"); err != nil {
+		t.Fatal(err)
+	}
+	if obj.Str != "hoge" {
+		t.Errorf("expected hoge but got %s", obj.Str)
+	}
+}
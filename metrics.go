@@ -0,0 +1,61 @@
+package avroturf
+
+import (
+	"strconv"
+)
+
+type Labels map[string]string
+
+type Metrics interface {
+	IncCounter(name string, labels Labels)
+	ObserveHistogram(name string, labels Labels, value float64)
+}
+
+const (
+	MetricDecodeTotal             = "avroturf_decode_total"
+	MetricDecodeDurationSeconds   = "avroturf_decode_duration_seconds"
+	MetricEncodeTotal             = "avroturf_encode_total"
+	MetricEncodeDurationSeconds   = "avroturf_encode_duration_seconds"
+	MetricRegistryRequestTotal    = "avroturf_registry_requests_total"
+	MetricRegistryRequestDuration = "avroturf_registry_request_duration_seconds"
+	MetricCacheHitsTotal          = "avroturf_cache_hits_total"
+	MetricCacheMissesTotal        = "avroturf_cache_misses_total"
+	MetricCacheEvictionsTotal     = "avroturf_cache_evictions_total"
+)
+
+func NewMetricsHooks(metrics Metrics) Hooks {
+	return HooksFunc(func(event Event) {
+		schemaID := strconv.FormatUint(uint64(event.SchemaID), 10)
+		switch event.Type {
+		case EventDecoded, EventDecodeFailed:
+			labels := Labels{"schema_id": schemaID, "result": eventResult(event)}
+			metrics.IncCounter(MetricDecodeTotal, labels)
+			metrics.ObserveHistogram(MetricDecodeDurationSeconds, labels, event.Duration.Seconds())
+		case EventEncoded, EventEncodeFailed:
+			labels := Labels{"subject": event.Subject, "schema_id": schemaID, "result": eventResult(event)}
+			metrics.IncCounter(MetricEncodeTotal, labels)
+			metrics.ObserveHistogram(MetricEncodeDurationSeconds, labels, event.Duration.Seconds())
+		case EventHTTPRequest:
+			status := "error"
+			if event.StatusCode != 0 {
+				status = strconv.Itoa(event.StatusCode)
+			}
+			labels := Labels{"method": event.Method, "endpoint": event.Endpoint, "status": status}
+			metrics.IncCounter(MetricRegistryRequestTotal, labels)
+			metrics.ObserveHistogram(MetricRegistryRequestDuration, labels, event.Duration.Seconds())
+		case EventCacheHit:
+			metrics.IncCounter(MetricCacheHitsTotal, Labels{})
+		case EventCacheMiss:
+			metrics.IncCounter(MetricCacheMissesTotal, Labels{})
+		case EventCacheEviction:
+			metrics.IncCounter(MetricCacheEvictionsTotal, Labels{})
+		}
+	})
+}
+
+func eventResult(event Event) string {
+	if event.Err != nil {
+		return "error"
+	}
+	return "success"
+}
@@ -0,0 +1,67 @@
+package avroturf_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+type recordedMetric struct {
+	name   string
+	labels avroturf.Labels
+}
+
+type metricsRecorder struct {
+	counters   []recordedMetric
+	histograms []recordedMetric
+}
+
+func (m *metricsRecorder) IncCounter(name string, labels avroturf.Labels) {
+	m.counters = append(m.counters, recordedMetric{name, labels})
+}
+
+func (m *metricsRecorder) ObserveHistogram(name string, labels avroturf.Labels, value float64) {
+	m.histograms = append(m.histograms, recordedMetric{name, labels})
+}
+
+func TestMetricsHooks(t *testing.T) {
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       &stubReadCloser{body: []byte(`{"id":3}`)},
+			}, nil
+		},
+	}
+	metrics := &metricsRecorder{}
+	messaging, err := avroturf.NewMessagingWithConfluent("test-namespace", "testdata/", "http://schema-registry:8081", avroturf.WithHooks(avroturf.NewMetricsHooks(metrics)), avroturf.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := messaging.Encode(record{Str: "hoge"}, "subject1", "test-name", "test-namespace"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectedCounters := []recordedMetric{
+		{avroturf.MetricCacheMissesTotal, avroturf.Labels{}},
+		{avroturf.MetricRegistryRequestTotal, avroturf.Labels{"method": "POST", "endpoint": "/subjects/{subject}/versions", "status": "200"}},
+		{avroturf.MetricEncodeTotal, avroturf.Labels{"subject": "subject1", "schema_id": "3", "result": "success"}},
+		{avroturf.MetricCacheHitsTotal, avroturf.Labels{}},
+		{avroturf.MetricEncodeTotal, avroturf.Labels{"subject": "subject1", "schema_id": "3", "result": "success"}},
+	}
+	if !reflect.DeepEqual(expectedCounters, metrics.counters) {
+		t.Errorf("expected %v but got %v", expectedCounters, metrics.counters)
+	}
+	expectedHistograms := []recordedMetric{
+		{avroturf.MetricRegistryRequestDuration, avroturf.Labels{"method": "POST", "endpoint": "/subjects/{subject}/versions", "status": "200"}},
+		{avroturf.MetricEncodeDurationSeconds, avroturf.Labels{"subject": "subject1", "schema_id": "3", "result": "success"}},
+		{avroturf.MetricEncodeDurationSeconds, avroturf.Labels{"subject": "subject1", "schema_id": "3", "result": "success"}},
+	}
+	if !reflect.DeepEqual(expectedHistograms, metrics.histograms) {
+		t.Errorf("expected %v but got %v", expectedHistograms, metrics.histograms)
+	}
+}
@@ -1,10 +1,11 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/wanabe/avroturf-go (interfaces: SchemaRegistry)
+// Source: github.com/wanabe/avroturf-go (interfaces: SchemaRegistry,ContextSchemaRegistry,SubjectRegistry)
 
 // Package mock_avroturf is a generated GoMock package.
 package mock_avroturf
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	avroturf "github.com/wanabe/avroturf-go"
 	reflect "reflect"
@@ -62,3 +63,169 @@ func (mr *MockSchemaRegistryMockRecorder) Register(arg0, arg1 interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockSchemaRegistry)(nil).Register), arg0, arg1)
 }
+
+// MockContextSchemaRegistry is a mock of ContextSchemaRegistry interface
+type MockContextSchemaRegistry struct {
+	ctrl     *gomock.Controller
+	recorder *MockContextSchemaRegistryMockRecorder
+}
+
+// MockContextSchemaRegistryMockRecorder is the mock recorder for MockContextSchemaRegistry
+type MockContextSchemaRegistryMockRecorder struct {
+	mock *MockContextSchemaRegistry
+}
+
+// NewMockContextSchemaRegistry creates a new mock instance
+func NewMockContextSchemaRegistry(ctrl *gomock.Controller) *MockContextSchemaRegistry {
+	mock := &MockContextSchemaRegistry{ctrl: ctrl}
+	mock.recorder = &MockContextSchemaRegistryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockContextSchemaRegistry) EXPECT() *MockContextSchemaRegistryMockRecorder {
+	return m.recorder
+}
+
+// FetchSchema mocks base method
+func (m *MockContextSchemaRegistry) FetchSchema(arg0 uint32) (*avroturf.Schema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchSchema", arg0)
+	ret0, _ := ret[0].(*avroturf.Schema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchSchema indicates an expected call of FetchSchema
+func (mr *MockContextSchemaRegistryMockRecorder) FetchSchema(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchSchema", reflect.TypeOf((*MockContextSchemaRegistry)(nil).FetchSchema), arg0)
+}
+
+// FetchSchemaContext mocks base method
+func (m *MockContextSchemaRegistry) FetchSchemaContext(arg0 context.Context, arg1 uint32) (*avroturf.Schema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchSchemaContext", arg0, arg1)
+	ret0, _ := ret[0].(*avroturf.Schema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchSchemaContext indicates an expected call of FetchSchemaContext
+func (mr *MockContextSchemaRegistryMockRecorder) FetchSchemaContext(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchSchemaContext", reflect.TypeOf((*MockContextSchemaRegistry)(nil).FetchSchemaContext), arg0, arg1)
+}
+
+// Register mocks base method
+func (m *MockContextSchemaRegistry) Register(arg0 string, arg1 *avroturf.Schema) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Register", arg0, arg1)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Register indicates an expected call of Register
+func (mr *MockContextSchemaRegistryMockRecorder) Register(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockContextSchemaRegistry)(nil).Register), arg0, arg1)
+}
+
+// RegisterContext mocks base method
+func (m *MockContextSchemaRegistry) RegisterContext(arg0 context.Context, arg1 string, arg2 *avroturf.Schema) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterContext", arg0, arg1, arg2)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterContext indicates an expected call of RegisterContext
+func (mr *MockContextSchemaRegistryMockRecorder) RegisterContext(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterContext", reflect.TypeOf((*MockContextSchemaRegistry)(nil).RegisterContext), arg0, arg1, arg2)
+}
+
+// MockSubjectRegistry is a mock of SubjectRegistry interface
+type MockSubjectRegistry struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubjectRegistryMockRecorder
+}
+
+// MockSubjectRegistryMockRecorder is the mock recorder for MockSubjectRegistry
+type MockSubjectRegistryMockRecorder struct {
+	mock *MockSubjectRegistry
+}
+
+// NewMockSubjectRegistry creates a new mock instance
+func NewMockSubjectRegistry(ctrl *gomock.Controller) *MockSubjectRegistry {
+	mock := &MockSubjectRegistry{ctrl: ctrl}
+	mock.recorder = &MockSubjectRegistryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSubjectRegistry) EXPECT() *MockSubjectRegistryMockRecorder {
+	return m.recorder
+}
+
+// FetchSchemaBySubjectVersion mocks base method
+func (m *MockSubjectRegistry) FetchSchemaBySubjectVersion(arg0 string, arg1 int) (*avroturf.RegisteredSchema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchSchemaBySubjectVersion", arg0, arg1)
+	ret0, _ := ret[0].(*avroturf.RegisteredSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchSchemaBySubjectVersion indicates an expected call of FetchSchemaBySubjectVersion
+func (mr *MockSubjectRegistryMockRecorder) FetchSchemaBySubjectVersion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchSchemaBySubjectVersion", reflect.TypeOf((*MockSubjectRegistry)(nil).FetchSchemaBySubjectVersion), arg0, arg1)
+}
+
+// FetchSchemaBySubjectVersionContext mocks base method
+func (m *MockSubjectRegistry) FetchSchemaBySubjectVersionContext(arg0 context.Context, arg1 string, arg2 int) (*avroturf.RegisteredSchema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchSchemaBySubjectVersionContext", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*avroturf.RegisteredSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchSchemaBySubjectVersionContext indicates an expected call of FetchSchemaBySubjectVersionContext
+func (mr *MockSubjectRegistryMockRecorder) FetchSchemaBySubjectVersionContext(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchSchemaBySubjectVersionContext", reflect.TypeOf((*MockSubjectRegistry)(nil).FetchSchemaBySubjectVersionContext), arg0, arg1, arg2)
+}
+
+// LookupSchema mocks base method
+func (m *MockSubjectRegistry) LookupSchema(arg0 string, arg1 *avroturf.Schema) (*avroturf.RegisteredSchema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookupSchema", arg0, arg1)
+	ret0, _ := ret[0].(*avroturf.RegisteredSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LookupSchema indicates an expected call of LookupSchema
+func (mr *MockSubjectRegistryMockRecorder) LookupSchema(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupSchema", reflect.TypeOf((*MockSubjectRegistry)(nil).LookupSchema), arg0, arg1)
+}
+
+// LookupSchemaContext mocks base method
+func (m *MockSubjectRegistry) LookupSchemaContext(arg0 context.Context, arg1 string, arg2 *avroturf.Schema) (*avroturf.RegisteredSchema, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookupSchemaContext", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*avroturf.RegisteredSchema)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LookupSchemaContext indicates an expected call of LookupSchemaContext
+func (mr *MockSubjectRegistryMockRecorder) LookupSchemaContext(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupSchemaContext", reflect.TypeOf((*MockSubjectRegistry)(nil).LookupSchemaContext), arg0, arg1, arg2)
+}
@@ -0,0 +1,167 @@
+package avroturf
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var DefaultHistogramBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type PrometheusMetrics struct {
+	sync.Mutex
+	Buckets []float64
+
+	families map[string]*metricFamily
+}
+
+type metricFamily struct {
+	kind       string
+	counters   map[string]float64
+	histograms map[string]*histogram
+}
+
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{Buckets: append([]float64(nil), DefaultHistogramBuckets...)}
+}
+
+func (p *PrometheusMetrics) IncCounter(name string, labels Labels) {
+	p.Lock()
+	defer p.Unlock()
+	p.family(name, "counter").counters[labelsKey(labels)]++
+}
+
+func (p *PrometheusMetrics) ObserveHistogram(name string, labels Labels, value float64) {
+	p.Lock()
+	defer p.Unlock()
+	f := p.family(name, "histogram")
+	key := labelsKey(labels)
+	h, hit := f.histograms[key]
+	if !hit {
+		h = &histogram{bounds: append([]float64(nil), p.Buckets...), counts: make([]uint64, len(p.Buckets))}
+		f.histograms[key] = h
+	}
+	for i, bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += value
+}
+
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(w, p.String())
+}
+
+func (p *PrometheusMetrics) String() string {
+	p.Lock()
+	defer p.Unlock()
+	names := make([]string, 0, len(p.families))
+	for name := range p.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b := &strings.Builder{}
+	for _, name := range names {
+		f := p.families[name]
+		fmt.Fprintf(b, "# TYPE %s %s\n", name, f.kind)
+		for _, key := range sortedSeries(f) {
+			labels := parseLabelsKey(key)
+			if f.kind == "counter" {
+				fmt.Fprintf(b, "%s%s %s\n", name, formatLabels(labels), formatFloat(f.counters[key]))
+				continue
+			}
+			h := f.histograms[key]
+			for i, bound := range h.bounds {
+				fmt.Fprintf(b, "%s_bucket%s %d\n", name, formatLabels(labels, "le", formatFloat(bound)), h.counts[i])
+			}
+			fmt.Fprintf(b, "%s_bucket%s %d\n", name, formatLabels(labels, "le", "+Inf"), h.count)
+			fmt.Fprintf(b, "%s_sum%s %s\n", name, formatLabels(labels), formatFloat(h.sum))
+			fmt.Fprintf(b, "%s_count%s %d\n", name, formatLabels(labels), h.count)
+		}
+	}
+	return b.String()
+}
+
+func (p *PrometheusMetrics) family(name string, kind string) *metricFamily {
+	if p.families == nil {
+		p.families = map[string]*metricFamily{}
+	}
+	f, hit := p.families[name]
+	if !hit {
+		f = &metricFamily{kind: kind, counters: map[string]float64{}, histograms: map[string]*histogram{}}
+		p.families[name] = f
+	}
+	return f
+}
+
+func sortedSeries(f *metricFamily) []string {
+	keys := make([]string, 0, len(f.counters)+len(f.histograms))
+	for key := range f.counters {
+		keys = append(keys, key)
+	}
+	for key := range f.histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func labelsKey(labels Labels) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "\x00" + labels[name]
+	}
+	return strings.Join(pairs, "\x01")
+}
+
+func parseLabelsKey(key string) []string {
+	if key == "" {
+		return nil
+	}
+	var labels []string
+	for _, pair := range strings.Split(key, "\x01") {
+		labels = append(labels, strings.SplitN(pair, "\x00", 2)...)
+	}
+	return labels
+}
+
+func formatLabels(labels []string, extra ...string) string {
+	labels = append(labels[:len(labels):len(labels)], extra...)
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, labels[i]+`="`+labelValueEscaper.Replace(labels[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
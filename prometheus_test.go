@@ -0,0 +1,44 @@
+package avroturf_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	p := avroturf.NewPrometheusMetrics()
+	p.Buckets = []float64{0.1, 1}
+	p.IncCounter("test_total", avroturf.Labels{"subject": `a"b`, "result": "success"})
+	p.IncCounter("test_total", avroturf.Labels{"result": "success", "subject": `a"b`})
+	p.IncCounter("test_total", avroturf.Labels{})
+	p.ObserveHistogram("test_seconds", avroturf.Labels{"endpoint": "/subjects"}, 0.5)
+	p.Buckets = append(p.Buckets, 5)
+	p.ObserveHistogram("test_seconds", avroturf.Labels{"endpoint": "/subjects"}, 2)
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	expected := `# TYPE test_seconds histogram
+test_seconds_bucket{endpoint="/subjects",le="0.1"} 0
+test_seconds_bucket{endpoint="/subjects",le="1"} 1
+test_seconds_bucket{endpoint="/subjects",le="+Inf"} 2
+test_seconds_sum{endpoint="/subjects"} 2.5
+test_seconds_count{endpoint="/subjects"} 2
+# TYPE test_total counter
+test_total 1
+test_total{result="success",subject="a\"b"} 2
+`
+	if rec.Body.String() != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+
+	d := avroturf.NewPrometheusMetrics()
+	d.Buckets[0] = 42
+	if avroturf.DefaultHistogramBuckets[0] == 42 {
+		t.Error("expected default buckets not to be shared")
+	}
+}
@@ -0,0 +1,87 @@
+package avroturf
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+type RefreshableTokenSource interface {
+	TokenSource
+	Invalidate(token string)
+}
+
+var errBasicAuthWithToken = errors.New("basic auth and a token source cannot be used together")
+
+type StaticTokenSource string
+
+func (s StaticTokenSource) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+type RefreshingTokenSource struct {
+	sync.Mutex
+	Fetch         func(ctx context.Context) (string, time.Time, error)
+	RefreshBefore time.Duration
+
+	token  string
+	expiry time.Time
+}
+
+func (s *RefreshingTokenSource) Token(ctx context.Context) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.token != "" && (s.expiry.IsZero() || time.Now().Add(s.RefreshBefore).Before(s.expiry)) {
+		return s.token, nil
+	}
+	token, expiry, err := s.Fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.token = token
+	s.expiry = expiry
+	return token, nil
+}
+
+func (s *RefreshingTokenSource) Invalidate(token string) {
+	s.Lock()
+	defer s.Unlock()
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+func (r *ConfluentSchemaRegistry) setAuthHeaders(ctx context.Context, req *http.Request) (string, error) {
+	if r.BasicAuthUserInfo != "" && r.TokenSource != nil {
+		return "", errBasicAuthWithToken
+	}
+	for key, values := range r.Headers {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	if r.BasicAuthUserInfo != "" {
+		user := strings.SplitN(r.BasicAuthUserInfo, ":", 2)
+		password := ""
+		if len(user) > 1 {
+			password = user[1]
+		}
+		req.SetBasicAuth(user[0], password)
+	}
+	if r.TokenSource != nil {
+		token, err := r.TokenSource.Token(ctx)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return token, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,140 @@
+package avroturf_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func TestRegistryBasicAuth(t *testing.T) {
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			user, password, ok := req.BasicAuth()
+			if !ok || user != "key" || password != "sec:ret" {
+				t.Errorf("unexpected basic auth: %s %s %v", user, password, ok)
+			}
+			if v := req.Header.Get("X-Tenant"); v != "tenant1" {
+				t.Errorf("expected static header but got %q", v)
+			}
+			if v := req.Header.Get("Content-type"); v != "application/json" {
+				t.Errorf("unexpected content type: %q", v)
+			}
+			return &http.Response{Body: &stubReadCloser{body: []byte(`["subject1"]`)}}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:        client,
+		RegistryURL:       "http://schema-registry:8081",
+		BasicAuthUserInfo: "key:sec:ret",
+		Headers:           http.Header{"X-Tenant": {"tenant1"}},
+	}
+	if _, err := r.ListSubjects(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryBearerToken(t *testing.T) {
+	var authorization string
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			authorization = req.Header.Get("Authorization")
+			return &http.Response{Body: &stubReadCloser{body: []byte(`["subject1"]`)}}, nil
+		},
+	}
+	fetches := 0
+	source := &avroturf.RefreshingTokenSource{
+		Fetch: func(ctx context.Context) (string, time.Time, error) {
+			fetches++
+			if fetches == 1 {
+				return "token1", time.Now().Add(-time.Second), nil
+			}
+			return "token2", time.Now().Add(time.Hour), nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:  client,
+		RegistryURL: "http://schema-registry:8081",
+		TokenSource: source,
+	}
+	expected := []string{"Bearer token1", "Bearer token2", "Bearer token2"}
+	for i, e := range expected {
+		if _, err := r.ListSubjects(); err != nil {
+			t.Fatal(err)
+		}
+		if authorization != e {
+			t.Errorf("request %d: expected %q but got %q", i, e, authorization)
+		}
+	}
+	if fetches != 2 {
+		t.Errorf("expected 2 token fetches but got %d", fetches)
+	}
+
+	r.TokenSource = avroturf.StaticTokenSource("static")
+	if _, err := r.ListSubjects(); err != nil {
+		t.Fatal(err)
+	}
+	if authorization != "Bearer static" {
+		t.Errorf("unexpected authorization: %q", authorization)
+	}
+}
+
+func TestRegistryRefreshesTokenOnUnauthorized(t *testing.T) {
+	var authorizations []string
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			authorization := req.Header.Get("Authorization")
+			authorizations = append(authorizations, authorization)
+			if authorization != "Bearer token2" {
+				return &http.Response{StatusCode: http.StatusUnauthorized, Body: &stubReadCloser{body: []byte(`{"error_code":401,"message":"Unauthorized"}`)}}, nil
+			}
+			return &http.Response{Body: &stubReadCloser{body: []byte(`["subject1"]`)}}, nil
+		},
+	}
+	fetches := 0
+	source := &avroturf.RefreshingTokenSource{
+		Fetch: func(ctx context.Context) (string, time.Time, error) {
+			fetches++
+			if fetches == 1 {
+				return "token1", time.Time{}, nil
+			}
+			return "token2", time.Time{}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{RegistryURL: "http://schema-registry:8081", TokenSource: source, HTTPClient: client}
+	if _, err := r.ListSubjects(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "[Bearer token1 Bearer token2]"; fmt.Sprint(authorizations) != expected {
+		t.Errorf("expected %s but got %v", expected, authorizations)
+	}
+
+	authorizations = nil
+	r.TokenSource = &avroturf.RefreshingTokenSource{
+		Fetch: func(ctx context.Context) (string, time.Time, error) {
+			return "revoked", time.Time{}, nil
+		},
+	}
+	var registryErr *avroturf.RegistryError
+	if _, err := r.ListSubjects(); !errors.As(err, &registryErr) || registryErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected unauthorized error but got %v", err)
+	}
+	if len(authorizations) != 2 {
+		t.Errorf("expected a single retry but got %v", authorizations)
+	}
+}
+
+func TestRegistryRejectsBasicAuthWithToken(t *testing.T) {
+	_, err := avroturf.NewConfluentSchemaRegistry(
+		"http://schema-registry:8081",
+		avroturf.WithBasicAuth("key:secret"),
+		avroturf.WithTokenSource(avroturf.StaticTokenSource("token")),
+	)
+	if expected := "basic auth and a token source cannot be used together"; err == nil || err.Error() != expected {
+		t.Errorf("expected '%s' but got %v", expected, err)
+	}
+}
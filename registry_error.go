@@ -0,0 +1,77 @@
+package avroturf
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+const (
+	ErrorCodeSubjectNotFound           = 40401
+	ErrorCodeVersionNotFound           = 40402
+	ErrorCodeSchemaNotFound            = 40403
+	ErrorCodeSubjectNotSoftDeleted     = 40405
+	ErrorCodeVersionNotSoftDeleted     = 40407
+	ErrorCodeSubjectLevelNotConfigured = 40408
+	ErrorCodeSubjectModeNotConfigured  = 40409
+	ErrorCodeIncompatibleSchema        = 409
+	ErrorCodeInvalidSchema             = 42201
+	ErrorCodeInvalidVersion            = 42202
+	ErrorCodeInvalidCompatibilityLevel = 42203
+	ErrorCodeInvalidMode               = 42204
+	ErrorCodeOperationNotPermitted     = 42205
+)
+
+type RegistryError struct {
+	StatusCode int
+	ErrorCode  int
+	Message    string
+}
+
+func (e *RegistryError) Error() string {
+	if e.ErrorCode == 0 {
+		return fmt.Sprintf("schema-registry error: status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("schema-registry error: status %d, error_code %d: %s", e.StatusCode, e.ErrorCode, e.Message)
+}
+
+func newRegistryError(statusCode int, data map[string]interface{}) *RegistryError {
+	e := &RegistryError{StatusCode: statusCode, Message: http.StatusText(statusCode)}
+	if code, ok := data["error_code"].(float64); ok {
+		e.ErrorCode = int(code)
+	}
+	if message, ok := data["message"].(string); ok {
+		e.Message = message
+	}
+	return e
+}
+
+func IsSubjectNotFound(err error) bool {
+	return hasErrorCode(err, ErrorCodeSubjectNotFound)
+}
+
+func IsVersionNotFound(err error) bool {
+	return hasErrorCode(err, ErrorCodeVersionNotFound)
+}
+
+func IsSchemaNotFound(err error) bool {
+	return hasErrorCode(err, ErrorCodeSchemaNotFound)
+}
+
+func IsIncompatibleSchema(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+func IsInvalidSchema(err error) bool {
+	return hasErrorCode(err, ErrorCodeInvalidSchema)
+}
+
+func hasErrorCode(err error, code int) bool {
+	var registryErr *RegistryError
+	return errors.As(err, &registryErr) && registryErr.ErrorCode == code
+}
+
+func hasStatusCode(err error, code int) bool {
+	var registryErr *RegistryError
+	return errors.As(err, &registryErr) && registryErr.StatusCode == code
+}
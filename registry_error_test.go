@@ -0,0 +1,102 @@
+package avroturf_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func TestRegistryErrorHelpers(t *testing.T) {
+	tests := []struct {
+		err                error
+		subjectNotFound    bool
+		schemaNotFound     bool
+		incompatibleSchema bool
+		invalidSchema      bool
+	}{
+		{&avroturf.RegistryError{StatusCode: 404, ErrorCode: 40401}, true, false, false, false},
+		{&avroturf.RegistryError{StatusCode: 404, ErrorCode: 40403}, false, true, false, false},
+		{&avroturf.RegistryError{StatusCode: 409, ErrorCode: 409}, false, false, true, false},
+		{&avroturf.RegistryError{StatusCode: 422, ErrorCode: 42201}, false, false, false, true},
+		{&avroturf.RegistryError{StatusCode: 422, ErrorCode: 42202}, false, false, false, false},
+		{&avroturf.RegistryError{StatusCode: 422, ErrorCode: 42203}, false, false, false, false},
+		{&avroturf.RegistryError{StatusCode: 422, ErrorCode: 42204}, false, false, false, false},
+		{&avroturf.RegistryError{StatusCode: 422, ErrorCode: 42205}, false, false, false, false},
+		{&avroturf.RegistryError{StatusCode: 422}, false, false, false, false},
+		{fmt.Errorf("wrapped: %w", &avroturf.RegistryError{StatusCode: 404, ErrorCode: 40403}), false, true, false, false},
+		{errors.New("other"), false, false, false, false},
+		{nil, false, false, false, false},
+	}
+	for _, test := range tests {
+		if actual := avroturf.IsSubjectNotFound(test.err); actual != test.subjectNotFound {
+			t.Errorf("IsSubjectNotFound(%v): expected %v but got %v", test.err, test.subjectNotFound, actual)
+		}
+		if actual := avroturf.IsSchemaNotFound(test.err); actual != test.schemaNotFound {
+			t.Errorf("IsSchemaNotFound(%v): expected %v but got %v", test.err, test.schemaNotFound, actual)
+		}
+		if actual := avroturf.IsIncompatibleSchema(test.err); actual != test.incompatibleSchema {
+			t.Errorf("IsIncompatibleSchema(%v): expected %v but got %v", test.err, test.incompatibleSchema, actual)
+		}
+		if actual := avroturf.IsInvalidSchema(test.err); actual != test.invalidSchema {
+			t.Errorf("IsInvalidSchema(%v): expected %v but got %v", test.err, test.invalidSchema, actual)
+		}
+	}
+}
+
+func TestFetchSchemaNotFound(t *testing.T) {
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			body := `{"error_code":40403,"message":"Schema not found"}`
+			return &http.Response{
+				StatusCode: 404,
+				Body: &stubReadCloser{
+					body: []byte(body),
+				},
+			}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:  client,
+		RegistryURL: "http://schema-registry:8081",
+	}
+	_, err := r.FetchSchema(uint32(135))
+	var registryErr *avroturf.RegistryError
+	if !errors.As(err, &registryErr) {
+		t.Fatalf("expected RegistryError but got %v", err)
+	}
+	expected := avroturf.RegistryError{StatusCode: 404, ErrorCode: 40403, Message: "Schema not found"}
+	if *registryErr != expected {
+		t.Errorf("expected %+v but got %+v", expected, *registryErr)
+	}
+	if !avroturf.IsSchemaNotFound(err) {
+		t.Errorf("expected IsSchemaNotFound to be true for %v", err)
+	}
+}
+
+func TestRegisterUnexpectedStatus(t *testing.T) {
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 500,
+				Body: &stubReadCloser{
+					body: []byte("Internal Server Error"),
+				},
+			}, nil
+		},
+	}
+	r := &avroturf.ConfluentSchemaRegistry{
+		HTTPClient:  client,
+		RegistryURL: "http://schema-registry:8081",
+	}
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Register("TestRecord", schema)
+	if err == nil || err.Error() != "schema-registry error: status 500: Internal Server Error" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
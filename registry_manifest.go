@@ -0,0 +1,213 @@
+package avroturf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const ManifestFileName = "manifest.json"
+
+type Manifest struct {
+	Compatibility CompatibilityLevel `json:"compatibility,omitempty" yaml:"compatibility,omitempty"`
+	Schemas       []ManifestSchema   `json:"schemas" yaml:"schemas"`
+	Subjects      []ManifestSubject  `json:"subjects" yaml:"subjects"`
+}
+
+type ManifestSchema struct {
+	ID   uint32 `json:"id" yaml:"id"`
+	File string `json:"file" yaml:"file"`
+}
+
+type ManifestSubject struct {
+	Subject       string             `json:"subject" yaml:"subject"`
+	Compatibility CompatibilityLevel `json:"compatibility,omitempty" yaml:"compatibility,omitempty"`
+	Versions      []ManifestVersion  `json:"versions" yaml:"versions"`
+}
+
+type ManifestVersion struct {
+	Version int    `json:"version" yaml:"version"`
+	ID      uint32 `json:"id" yaml:"id"`
+}
+
+func ExportManifest(ctx context.Context, r *ConfluentSchemaRegistry, dir string) (*Manifest, error) {
+	subjects, err := r.ListSubjectsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	global, err := r.GetCompatibilityContext(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{Compatibility: global, Schemas: []ManifestSchema{}, Subjects: []ManifestSubject{}}
+	exported := map[uint32]bool{}
+	for _, subject := range subjects {
+		versions, err := r.ListVersionsContext(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		ms := ManifestSubject{Subject: subject}
+		level, err := r.GetCompatibilityContext(ctx, subject)
+		if err != nil {
+			return nil, err
+		}
+		if level != global {
+			ms.Compatibility = level
+		}
+		for _, version := range versions {
+			registered, err := r.FetchSchemaBySubjectVersionContext(ctx, subject, version)
+			if err != nil {
+				return nil, err
+			}
+			ms.Versions = append(ms.Versions, ManifestVersion{Version: registered.Version, ID: registered.ID})
+			if exported[registered.ID] {
+				continue
+			}
+			exported[registered.ID] = true
+			file := path.Join("schemas", strconv.FormatUint(uint64(registered.ID), 10)+".avsc")
+			if err := writeManifestFile(dir, file, []byte(registered.Schema.String())); err != nil {
+				return nil, err
+			}
+			manifest.Schemas = append(manifest.Schemas, ManifestSchema{ID: registered.ID, File: file})
+		}
+		manifest.Subjects = append(manifest.Subjects, ms)
+	}
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeManifestFile(dir, ManifestFileName, append(b, '\n')); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func ImportManifest(ctx context.Context, r *ConfluentSchemaRegistry, dir string) error {
+	manifest, schemas, err := loadManifest(NewSchemaStore(dir), ManifestFileName)
+	if err != nil {
+		return err
+	}
+	var restores []func() error
+	for _, subject := range manifest.Subjects {
+		var restore func() error
+		if restore, err = enterImportMode(ctx, r, subject.Subject); err != nil {
+			break
+		}
+		restores = append(restores, restore)
+	}
+	if err == nil {
+		err = importManifest(ctx, r, manifest, schemas)
+	}
+	for _, restore := range restores {
+		if restoreErr := restore(); err == nil {
+			err = restoreErr
+		}
+	}
+	return err
+}
+
+func enterImportMode(ctx context.Context, r *ConfluentSchemaRegistry, subject string) (func() error, error) {
+	restore := func() error {
+		_, err := r.DeleteModeContext(context.Background(), subject)
+		return err
+	}
+	previous, err := r.GetModeContext(ctx, subject)
+	switch {
+	case err == nil:
+		restore = func() error {
+			_, err := r.SetModeContext(context.Background(), subject, previous)
+			return err
+		}
+	case !IsSubjectNotFound(err) && !hasErrorCode(err, ErrorCodeSubjectModeNotConfigured):
+		return nil, err
+	}
+	if _, err := r.SetModeContext(ctx, subject, ModeImport); err != nil {
+		return nil, err
+	}
+	return restore, nil
+}
+
+func importManifest(ctx context.Context, r *ConfluentSchemaRegistry, manifest *Manifest, schemas map[uint32]*Schema) error {
+	for _, subject := range manifest.Subjects {
+		for _, version := range subject.Versions {
+			_, err := r.RegisterWithIDContext(ctx, subject.Subject, schemas[version.ID], version.ID, version.Version)
+			if err != nil {
+				return fmt.Errorf("%s version %d: %v", subject.Subject, version.Version, err)
+			}
+		}
+	}
+	if manifest.Compatibility != "" {
+		if _, err := r.SetCompatibilityContext(ctx, "", manifest.Compatibility); err != nil {
+			return err
+		}
+	}
+	for _, subject := range manifest.Subjects {
+		if subject.Compatibility == "" {
+			continue
+		}
+		if _, err := r.SetCompatibilityContext(ctx, subject.Subject, subject.Compatibility); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isYAMLManifest(manifestPath string) bool {
+	switch strings.ToLower(path.Ext(manifestPath)) {
+	case ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+func loadManifest(store *SchemaStore, manifestPath string) (*Manifest, map[uint32]*Schema, error) {
+	manifestPath = path.Join(store.Path, manifestPath)
+	b, err := store.readFile(manifestPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	unmarshal := json.Unmarshal
+	if isYAMLManifest(manifestPath) {
+		unmarshal = yaml.Unmarshal
+	}
+	manifest := &Manifest{}
+	if err := unmarshal(b, manifest); err != nil {
+		return nil, nil, fmt.Errorf("%s: %v", manifestPath, err)
+	}
+	schemas := map[uint32]*Schema{}
+	for _, entry := range manifest.Schemas {
+		avsc, err := store.readFile(path.Join(path.Dir(manifestPath), entry.File))
+		if err != nil {
+			return nil, nil, err
+		}
+		schema, err := Parse(string(avsc))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", entry.File, err)
+		}
+		schemas[entry.ID] = schema
+	}
+	for _, subject := range manifest.Subjects {
+		for _, version := range subject.Versions {
+			if _, hit := schemas[version.ID]; !hit {
+				return nil, nil, fmt.Errorf("%s: subject %s version %d refers to unknown schema id %d", manifestPath, subject.Subject, version.Version, version.ID)
+			}
+		}
+	}
+	return manifest, schemas, nil
+}
+
+func writeManifestFile(dir string, file string, content []byte) error {
+	filename := filepath.Join(dir, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, content, 0644)
+}
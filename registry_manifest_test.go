@@ -0,0 +1,157 @@
+package avroturf_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+	"github.com/wanabe/avroturf-go/avroturftest"
+)
+
+func TestImportManifest(t *testing.T) {
+	source := avroturftest.NewRegistry()
+	defer source.Close()
+	src := source.SchemaRegistry()
+
+	v1 := mustParse(t, `{"type":"record","name":"TestSchemaRoot","fields":[{"name":"str","type":"string"}]}`)
+	v2 := mustParse(t, `{"type":"record","name":"TestSchemaRoot","fields":[{"name":"str","type":"string"},{"name":"num","type":"long","default":7}]}`)
+	other := mustParse(t, `"string"`)
+	for _, reg := range []struct {
+		subject string
+		schema  *avroturf.Schema
+	}{{"other-value", other}, {"test-value", v1}, {"test-value", v2}} {
+		if _, err := src.Register(reg.subject, reg.schema); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := src.SetCompatibility("other-value", avroturf.CompatibilityFull); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "avroturf-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	manifest, err := avroturf.ExportManifest(context.Background(), src, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Compatibility != avroturf.CompatibilityBackward {
+		t.Errorf("expected global compatibility to be exported but got %q", manifest.Compatibility)
+	}
+
+	target := avroturftest.NewRegistry()
+	defer target.Close()
+	dst := target.SchemaRegistry()
+	if _, err := dst.SetCompatibility("", avroturf.CompatibilityNone); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.SetMode("", avroturf.ModeReadOnly); err != nil {
+		t.Fatal(err)
+	}
+	if err := avroturf.ImportManifest(context.Background(), dst, dir); err != nil {
+		t.Fatal(err)
+	}
+	if mode, err := dst.GetMode(""); err != nil || mode != avroturf.ModeReadOnly {
+		t.Errorf("expected global mode to be left alone but got %q (%v)", mode, err)
+	}
+	for _, subject := range []string{"other-value", "test-value"} {
+		if mode, err := dst.GetMode(subject); !isModeNotConfigured(err) {
+			t.Errorf("%s: expected subject mode to be removed but got %q (%v)", subject, mode, err)
+		}
+	}
+	if _, err := dst.Register("test-value", mustParse(t, `"string"`)); err == nil {
+		t.Error("expected the global read-only mode to apply after the import")
+	}
+	if _, err := dst.SetMode("", avroturf.ModeReadWrite); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, subject := range []string{"other-value", "test-value"} {
+		expected, err := src.ListVersions(subject)
+		if err != nil {
+			t.Fatal(err)
+		}
+		versions, err := dst.ListVersions(subject)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(expected, versions) {
+			t.Errorf("%s: expected versions %v but got %v", subject, expected, versions)
+		}
+		for _, version := range versions {
+			want, err := src.FetchSchemaBySubjectVersion(subject, version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := dst.FetchSchemaBySubjectVersion(subject, version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.ID != want.ID || got.Schema.String() != want.Schema.String() {
+				t.Errorf("%s version %d: expected %+v but got %+v", subject, version, want, got)
+			}
+		}
+	}
+	if level, err := dst.GetCompatibility(""); err != nil || level != avroturf.CompatibilityBackward {
+		t.Errorf("expected global compatibility BACKWARD but got %q (%v)", level, err)
+	}
+	if level, err := dst.GetCompatibility("other-value"); err != nil || level != avroturf.CompatibilityFull {
+		t.Errorf("expected subject compatibility FULL but got %q (%v)", level, err)
+	}
+	id, err := dst.Register("test-value", mustParse(t, `{"type":"record","name":"TestSchemaRoot","fields":[{"name":"str","type":"string"},{"name":"num","type":"long","default":7},{"name":"flag","type":"boolean","default":false}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 4 {
+		t.Errorf("expected new schema to be assigned id 4 but got %d", id)
+	}
+}
+
+func TestImportManifestRestoresModesOnFailure(t *testing.T) {
+	source := avroturftest.NewRegistry()
+	defer source.Close()
+	src := source.SchemaRegistry()
+	for _, subject := range []string{"other-value", "test-value"} {
+		if _, err := src.Register(subject, mustParse(t, `"string"`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir, err := ioutil.TempDir("", "avroturf-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := avroturf.ExportManifest(context.Background(), src, dir); err != nil {
+		t.Fatal(err)
+	}
+
+	target := avroturftest.NewRegistry()
+	defer target.Close()
+	dst := target.SchemaRegistry()
+	if _, err := dst.Register("test-value", mustParse(t, `"long"`)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.SetMode("test-value", avroturf.ModeReadOnly); err != nil {
+		t.Fatal(err)
+	}
+	if err := avroturf.ImportManifest(context.Background(), dst, dir); err == nil {
+		t.Fatal("expected import to fail on a conflicting schema id")
+	}
+	if mode, err := dst.GetMode("test-value"); err != nil || mode != avroturf.ModeReadOnly {
+		t.Errorf("expected previous subject mode to be restored but got %q (%v)", mode, err)
+	}
+	if mode, err := dst.GetMode("other-value"); !isModeNotConfigured(err) {
+		t.Errorf("expected new subject to fall back to the global mode but got %q (%v)", mode, err)
+	}
+}
+
+func isModeNotConfigured(err error) bool {
+	var registryErr *avroturf.RegistryError
+	return errors.As(err, &registryErr) && registryErr.ErrorCode == avroturf.ErrorCodeSubjectModeNotConfigured
+}
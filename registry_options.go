@@ -0,0 +1,106 @@
+package avroturf
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+type RegistryOption func(*ConfluentSchemaRegistry, *registryConfig)
+
+type registryConfig struct {
+	tlsConfig *tls.Config
+	timeout   time.Duration
+}
+
+func WithHTTPClient(client HTTPDoer) RegistryOption {
+	return func(r *ConfluentSchemaRegistry, _ *registryConfig) {
+		r.HTTPClient = client
+	}
+}
+
+func WithTLSConfig(tlsConfig *tls.Config) RegistryOption {
+	return func(_ *ConfluentSchemaRegistry, c *registryConfig) {
+		c.tlsConfig = tlsConfig
+	}
+}
+
+func WithTimeout(timeout time.Duration) RegistryOption {
+	return func(_ *ConfluentSchemaRegistry, c *registryConfig) {
+		c.timeout = timeout
+	}
+}
+
+func WithLogger(logger *log.Logger) RegistryOption {
+	return func(r *ConfluentSchemaRegistry, _ *registryConfig) {
+		r.Logger = logger
+	}
+}
+
+func WithHooks(hooks Hooks) RegistryOption {
+	return func(r *ConfluentSchemaRegistry, _ *registryConfig) {
+		r.Hooks = hooks
+	}
+}
+
+func WithRegistryURLs(urls ...string) RegistryOption {
+	return func(r *ConfluentSchemaRegistry, _ *registryConfig) {
+		r.RegistryURLs = append(r.RegistryURLs, urls...)
+	}
+}
+
+func WithRetry(retry RetryPolicy) RegistryOption {
+	return func(r *ConfluentSchemaRegistry, _ *registryConfig) {
+		r.Retry = retry
+	}
+}
+
+func WithBasicAuth(userInfo string) RegistryOption {
+	return func(r *ConfluentSchemaRegistry, _ *registryConfig) {
+		r.BasicAuthUserInfo = userInfo
+	}
+}
+
+func WithTokenSource(source TokenSource) RegistryOption {
+	return func(r *ConfluentSchemaRegistry, _ *registryConfig) {
+		r.TokenSource = source
+	}
+}
+
+func WithHeaders(headers http.Header) RegistryOption {
+	return func(r *ConfluentSchemaRegistry, _ *registryConfig) {
+		if r.Headers == nil {
+			r.Headers = http.Header{}
+		}
+		for key, values := range headers {
+			r.Headers[key] = append(r.Headers[key], values...)
+		}
+	}
+}
+
+func LoadTLSConfig(caFile string, certFile string, keyFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{}
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
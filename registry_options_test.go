@@ -0,0 +1,121 @@
+package avroturf_test
+
+import (
+	"bytes"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func TestRegistryPerInstanceClientAndLogger(t *testing.T) {
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{Body: &stubReadCloser{body: []byte(`{"schema":"\"string\""}`)}}, nil
+		},
+	}
+	buf := &bytes.Buffer{}
+	r, err := avroturf.NewConfluentSchemaRegistry(
+		"http://schema-registry:8081",
+		avroturf.WithHTTPClient(client),
+		avroturf.WithLogger(log.New(buf, "", 0)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.FetchSchema(135); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Fetching schema with id 135\n"; buf.String() != expected {
+		t.Errorf("expected %q but got %q", expected, buf.String())
+	}
+
+	messaging, err := avroturf.NewMessagingWithConfluent("test-namespace", "testdata/", "http://schema-registry:8081", avroturf.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := messaging.GetSchema([]byte{0, 0, 0, 0, 135}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistryTLSConfig(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`["subject1"]`))
+	}))
+	defer srv.Close()
+
+	caFile, err := ioutil.TempFile("", "avroturf-ca")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(caFile.Name())
+	pem.Encode(caFile, &pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	caFile.Close()
+
+	r, err := avroturf.NewConfluentSchemaRegistry(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ListSubjects(); err == nil || !strings.Contains(err.Error(), "certificate") {
+		t.Errorf("expected certificate error with the default client but got %v", err)
+	}
+
+	tlsConfig, err := avroturf.LoadTLSConfig(caFile.Name(), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err = avroturf.NewConfluentSchemaRegistry(srv.URL, avroturf.WithTLSConfig(tlsConfig), avroturf.WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subjects, err := r.ListSubjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subjects) != 1 || subjects[0] != "subject1" {
+		t.Errorf("unexpected subjects: %v", subjects)
+	}
+}
+
+func TestRegistryRejectsTLSWithCustomClient(t *testing.T) {
+	_, err := avroturf.NewConfluentSchemaRegistry(
+		"http://schema-registry:8081",
+		avroturf.WithHTTPClient(&http.Client{}),
+		avroturf.WithTimeout(5*time.Second),
+	)
+	if err == nil {
+		t.Error("expected an error when combining a custom client with a timeout")
+	}
+}
+
+func TestRegistryFallsBackToGlobals(t *testing.T) {
+	client := &httpClientStub{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{Body: &stubReadCloser{body: []byte(`{"schema":"\"string\""}`)}}, nil
+		},
+	}
+	buf := &bytes.Buffer{}
+	defer func(client avroturf.HTTPDoer, logger *log.Logger) {
+		avroturf.HTTPClient, avroturf.Logger = client, logger
+	}(avroturf.HTTPClient, avroturf.Logger)
+	avroturf.HTTPClient, avroturf.Logger = client, log.New(buf, "", 0)
+
+	r, err := avroturf.NewConfluentSchemaRegistry("http://schema-registry:8081")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.FetchSchema(135); err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Fetching schema with id 135\n"; buf.String() != expected {
+		t.Errorf("expected %q but got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,217 @@
+package avroturf
+
+import (
+	"fmt"
+
+	"github.com/hamba/avro"
+)
+
+func Resolve(writer *Schema, reader *Schema, data []byte) ([]byte, error) {
+	v, err := readDatum(avro.NewReader(nil, 0).Reset(data), writer.Schema)
+	if err != nil {
+		return nil, err
+	}
+	v, err = resolveDatum(writer.Schema, reader.Schema, v)
+	if err != nil {
+		return nil, err
+	}
+	w := avro.NewWriter(nil, len(data))
+	if err := writeDatum(w, reader.Schema, v); err != nil {
+		return nil, err
+	}
+	return w.Buffer(), nil
+}
+
+func resolveDatum(writer avro.Schema, reader avro.Schema, v interface{}) (interface{}, error) {
+	writer = derefSchema(writer)
+	reader = derefSchema(reader)
+
+	if w, ok := writer.(*avro.UnionSchema); ok {
+		name, val, err := unionBranch(v)
+		if err != nil {
+			return nil, err
+		}
+		branch, _ := findUnionBranch(w, name)
+		if branch == nil {
+			return nil, fmt.Errorf("unknown union branch: %s", name)
+		}
+		return resolveDatum(branch, reader, val)
+	}
+
+	if r, ok := reader.(*avro.UnionSchema); ok {
+		branch := matchUnionBranch(writer, r)
+		if branch == nil {
+			return nil, fmt.Errorf("reader union %s has no branch matching %s", r.String(), unionBranchName(writer))
+		}
+		if branch.Type() == avro.Null {
+			return nil, nil
+		}
+		val, err := resolveDatum(writer, branch, v)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{unionBranchName(branch): val}, nil
+	}
+
+	if writer.Type() != reader.Type() {
+		return promoteDatum(writer, reader, v)
+	}
+
+	switch r := reader.(type) {
+	case *avro.RecordSchema:
+		w := writer.(*avro.RecordSchema)
+		if w.FullName() != r.FullName() {
+			return nil, fmt.Errorf("record name mismatch: writer %s, reader %s", w.FullName(), r.FullName())
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected map for record %s but got %T", w.FullName(), v)
+		}
+		writerFields := map[string]*avro.Field{}
+		for _, field := range w.Fields() {
+			writerFields[field.Name()] = field
+		}
+		resolved := make(map[string]interface{}, len(r.Fields()))
+		for _, field := range r.Fields() {
+			wf, hit := writerFields[field.Name()]
+			if !hit {
+				if !field.HasDefault() {
+					return nil, fmt.Errorf("field %s.%s is missing in writer schema and has no default", r.FullName(), field.Name())
+				}
+				def, err := defaultDatum(field.Type(), field.Default())
+				if err != nil {
+					return nil, err
+				}
+				resolved[field.Name()] = def
+				continue
+			}
+			val, err := resolveDatum(wf.Type(), field.Type(), obj[field.Name()])
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %v", r.FullName(), field.Name(), err)
+			}
+			resolved[field.Name()] = val
+		}
+		return resolved, nil
+	case *avro.EnumSchema:
+		w := writer.(*avro.EnumSchema)
+		if w.FullName() != r.FullName() {
+			return nil, fmt.Errorf("enum name mismatch: writer %s, reader %s", w.FullName(), r.FullName())
+		}
+		for _, symbol := range r.Symbols() {
+			if symbol == v {
+				return v, nil
+			}
+		}
+		if def, ok := r.Prop("default").(string); ok {
+			return def, nil
+		}
+		return nil, fmt.Errorf("symbol %v is unknown to reader enum %s", v, r.FullName())
+	case *avro.FixedSchema:
+		w := writer.(*avro.FixedSchema)
+		if w.FullName() != r.FullName() || w.Size() != r.Size() {
+			return nil, fmt.Errorf("fixed mismatch: writer %s(%d), reader %s(%d)", w.FullName(), w.Size(), r.FullName(), r.Size())
+		}
+		return v, nil
+	case *avro.ArraySchema:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected slice for array but got %T", v)
+		}
+		items := make([]interface{}, len(arr))
+		for i, item := range arr {
+			val, err := resolveDatum(writer.(*avro.ArraySchema).Items(), r.Items(), item)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = val
+		}
+		return items, nil
+	case *avro.MapSchema:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected map for map but got %T", v)
+		}
+		values := make(map[string]interface{}, len(obj))
+		for key, val := range obj {
+			resolved, err := resolveDatum(writer.(*avro.MapSchema).Values(), r.Values(), val)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = resolved
+		}
+		return values, nil
+	}
+	return v, nil
+}
+
+func promoteDatum(writer avro.Schema, reader avro.Schema, v interface{}) (interface{}, error) {
+	switch reader.Type() {
+	case avro.Long:
+		if i, ok := v.(int32); ok {
+			return int64(i), nil
+		}
+	case avro.Float:
+		switch n := v.(type) {
+		case int32:
+			return float32(n), nil
+		case int64:
+			return float32(n), nil
+		}
+	case avro.Double:
+		switch n := v.(type) {
+		case int32:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case float32:
+			return float64(n), nil
+		}
+	case avro.Bytes:
+		if str, ok := v.(string); ok {
+			return []byte(str), nil
+		}
+	case avro.String:
+		if b, ok := v.([]byte); ok {
+			return string(b), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot promote %s to %s", writer.Type(), reader.Type())
+}
+
+func matchUnionBranch(writer avro.Schema, reader *avro.UnionSchema) avro.Schema {
+	for _, t := range reader.Types() {
+		t = derefSchema(t)
+		if t.Type() == writer.Type() && unionBranchName(t) == unionBranchName(writer) {
+			return t
+		}
+	}
+	for _, t := range reader.Types() {
+		t = derefSchema(t)
+		if t.Type() == writer.Type() {
+			return t
+		}
+	}
+	for _, t := range reader.Types() {
+		t = derefSchema(t)
+		if isPromotable(writer.Type(), t.Type()) {
+			return t
+		}
+	}
+	return nil
+}
+
+func isPromotable(writer avro.Type, reader avro.Type) bool {
+	switch writer {
+	case avro.Int:
+		return reader == avro.Long || reader == avro.Float || reader == avro.Double
+	case avro.Long:
+		return reader == avro.Float || reader == avro.Double
+	case avro.Float:
+		return reader == avro.Double
+	case avro.String:
+		return reader == avro.Bytes
+	case avro.Bytes:
+		return reader == avro.String
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package avroturf_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hamba/avro"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func mustParse(t *testing.T, str string) *avroturf.Schema {
+	t.Helper()
+	s, err := avroturf.Parse(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestResolve(t *testing.T) {
+	writer := mustParse(t, `
+		{
+			"type": "record",
+			"name": "TestRecord",
+			"fields": [
+				{"name": "removed", "type": "string"},
+				{"name": "num", "type": "int"},
+				{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["RED", "GREEN", "PURPLE"]}},
+				{"name": "opt", "type": ["null", "int"]},
+				{"name": "str", "type": "string"},
+				{"name": "nums", "type": {"type": "array", "items": "int"}}
+			]
+		}
+	`)
+	reader := mustParse(t, `
+		{
+			"type": "record",
+			"name": "TestRecord",
+			"fields": [
+				{"name": "str", "type": "bytes"},
+				{"name": "num", "type": "double"},
+				{"name": "added", "type": "string", "default": "def"},
+				{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["UNKNOWN", "RED", "GREEN"], "default": "UNKNOWN"}},
+				{"name": "opt", "type": ["null", "long"]},
+				{"name": "nums", "type": {"type": "array", "items": "long"}}
+			]
+		}
+	`)
+	type writerRecord struct {
+		Removed string `avro:"removed"`
+		Num     int    `avro:"num"`
+		Color   string `avro:"color"`
+		Opt     *int   `avro:"opt"`
+		Str     string `avro:"str"`
+		Nums    []int  `avro:"nums"`
+	}
+	type readerRecord struct {
+		Str   []byte  `avro:"str"`
+		Num   float64 `avro:"num"`
+		Added string  `avro:"added"`
+		Color string  `avro:"color"`
+		Opt   *int64  `avro:"opt"`
+		Nums  []int64 `avro:"nums"`
+	}
+	opt := 5
+	data, err := avro.Marshal(writer.Schema, writerRecord{Removed: "x", Num: 3, Color: "PURPLE", Opt: &opt, Str: "hoge", Nums: []int{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolved, err := avroturf.Resolve(writer, reader, data)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	obj := readerRecord{}
+	err = avro.Unmarshal(reader.Schema, resolved, &obj)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	optExpected := int64(5)
+	expected := readerRecord{Str: []byte("hoge"), Num: 3, Added: "def", Color: "UNKNOWN", Opt: &optExpected, Nums: []int64{1, 2}}
+	if !reflect.DeepEqual(expected, obj) {
+		t.Errorf("expected %+v but got %+v", expected, obj)
+	}
+}
+
+func TestResolveIdentical(t *testing.T) {
+	schema := mustParse(t, `
+		{
+			"type": "record",
+			"name": "TestRecord",
+			"fields": [
+				{"name": "id", "type": {"type": "fixed", "name": "ID", "size": 2}},
+				{"name": "children", "type": {"type": "array", "items": {"type": "record", "name": "Child", "fields": [{"name": "str", "type": "string"}]}}}
+			]
+		}
+	`)
+	type child struct {
+		Str string `avro:"str"`
+	}
+	type testRecord struct {
+		ID       [2]byte `avro:"id"`
+		Children []child `avro:"children"`
+	}
+	src := testRecord{ID: [2]byte{1, 2}, Children: []child{{Str: "a"}, {Str: "b"}}}
+	data, err := avro.Marshal(schema.Schema, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved, err := avroturf.Resolve(schema, schema, data)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	dst := testRecord{}
+	if err := avro.Unmarshal(schema.Schema, resolved, &dst); err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if !reflect.DeepEqual(src, dst) {
+		t.Errorf("expected %+v but got %+v", src, dst)
+	}
+}
+
+func TestFailResolve(t *testing.T) {
+	writer := mustParse(t, `{"type": "record", "name": "TestRecord", "fields": [{"name": "str", "type": "string"}]}`)
+	data, err := avro.Marshal(writer.Schema, map[string]interface{}{"str": "hoge"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"missing default": `{"type": "record", "name": "TestRecord", "fields": [{"name": "str", "type": "string"}, {"name": "num", "type": "int"}]}`,
+		"type mismatch":   `{"type": "record", "name": "TestRecord", "fields": [{"name": "str", "type": "int"}]}`,
+		"name mismatch":   `{"type": "record", "name": "OtherRecord", "fields": [{"name": "str", "type": "string"}]}`,
+		"union mismatch":  `{"type": "record", "name": "TestRecord", "fields": [{"name": "str", "type": ["null", "int"]}]}`,
+	}
+	for name, readerStr := range tests {
+		reader := mustParse(t, readerStr)
+		_, err := avroturf.Resolve(writer, reader, data)
+		if err == nil {
+			t.Errorf("%s: expected error but got nil", name)
+		}
+	}
+}
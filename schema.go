@@ -23,7 +23,7 @@ func Parse(str string) (*Schema, error) {
 	}
 
 	s := Schema{str: string(b)}
-	s.Schema, err = avro.Parse(s.str)
+	s.Schema, err = avro.ParseWithCache(s.str, "", &avro.SchemaCache{})
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,16 @@
+package avroturf
+
+type SchemaCache interface {
+	LookupSchemaByID(schemaID uint32) *Schema
+	StoreSchemaByID(schemaID uint32, schema *Schema) *Schema
+	LookupIdBySchema(subject string, schema *Schema) uint32
+	StoreIdBySchema(subject string, schema *Schema, schemaID uint32) uint32
+	LookupSchemaBySubjectVersion(subject string, version int) *Schema
+	StoreSchemaBySubjectVersion(subject string, version int, schema *Schema) *Schema
+	LookupVersionBySchema(subject string, schema *Schema) int
+	StoreVersionBySchema(subject string, schema *Schema, version int) int
+}
+
+type LatestVersionCache interface {
+	CachesLatestVersion() bool
+}
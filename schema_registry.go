@@ -1,7 +1,36 @@
 package avroturf
 
-//go:generate mockgen -destination=mock_avroturf/mock_schema_registry.go -package mock_avroturf github.com/wanabe/avroturf-go SchemaRegistry
+import "context"
+
+//go:generate mockgen -destination=mock_avroturf/mock_schema_registry.go -package mock_avroturf github.com/wanabe/avroturf-go SchemaRegistry,ContextSchemaRegistry,SubjectRegistry
 type SchemaRegistry interface {
 	FetchSchema(schemaID uint32) (*Schema, error)
 	Register(subject string, schema *Schema) (uint32, error)
 }
+
+type ContextSchemaRegistry interface {
+	SchemaRegistry
+	FetchSchemaContext(ctx context.Context, schemaID uint32) (*Schema, error)
+	RegisterContext(ctx context.Context, subject string, schema *Schema) (uint32, error)
+}
+
+type SubjectRegistry interface {
+	FetchSchemaBySubjectVersion(subject string, version int) (*RegisteredSchema, error)
+	FetchSchemaBySubjectVersionContext(ctx context.Context, subject string, version int) (*RegisteredSchema, error)
+	LookupSchema(subject string, schema *Schema) (*RegisteredSchema, error)
+	LookupSchemaContext(ctx context.Context, subject string, schema *Schema) (*RegisteredSchema, error)
+}
+
+func fetchSchemaContext(ctx context.Context, r SchemaRegistry, schemaID uint32) (*Schema, error) {
+	if cr, ok := r.(ContextSchemaRegistry); ok {
+		return cr.FetchSchemaContext(ctx, schemaID)
+	}
+	return r.FetchSchema(schemaID)
+}
+
+func registerContext(ctx context.Context, r SchemaRegistry, subject string, schema *Schema) (uint32, error) {
+	if cr, ok := r.(ContextSchemaRegistry); ok {
+		return cr.RegisterContext(ctx, subject, schema)
+	}
+	return r.Register(subject, schema)
+}
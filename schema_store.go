@@ -3,13 +3,15 @@ package avroturf
 import (
 	"io/ioutil"
 	"net/http"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 )
 
 type SchemaStore struct {
-	sync.Mutex
+	sync.RWMutex
 	Path    string
 	FS      http.FileSystem
 	schemas map[string]*Schema
@@ -27,7 +29,9 @@ func (store *SchemaStore) Find(schemaName string, namespace string) (*Schema, er
 	if namespace != "" {
 		fullName = namespace + "." + schemaName
 	}
+	store.RLock()
 	schema, hit := store.schemas[fullName]
+	store.RUnlock()
 	if hit {
 		return schema, nil
 	}
@@ -40,6 +44,49 @@ func (store *SchemaStore) Find(schemaName string, namespace string) (*Schema, er
 	return store.loadSchema(fullName)
 }
 
+func (store *SchemaStore) FullNames() ([]string, error) {
+	var names []string
+	err := store.walk(store.Path, nil, func(elem []string) {
+		names = append(names, strings.Join(elem, "."))
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (store *SchemaStore) walk(dir string, elem []string, fn func(elem []string)) error {
+	infos, err := store.readDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		next := append(elem[:len(elem):len(elem)], strings.TrimSuffix(name, ".avsc"))
+		if info.IsDir() {
+			if err := store.walk(filepath.Join(dir, name), next, fn); err != nil {
+				return err
+			}
+		} else if strings.HasSuffix(name, ".avsc") {
+			fn(next)
+		}
+	}
+	return nil
+}
+
+func (store *SchemaStore) readDir(dir string) ([]os.FileInfo, error) {
+	if store.FS != nil {
+		f, err := store.FS.Open(dir)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return f.Readdir(-1)
+	}
+	return ioutil.ReadDir(dir)
+}
+
 func (store *SchemaStore) loadSchema(fullName string) (*Schema, error) {
 	slicedPath := append([]string{store.Path}, strings.Split(fullName, ".")...)
 	slicedPath[len(slicedPath)-1] = slicedPath[len(slicedPath)-1] + ".avsc"
@@ -53,6 +100,9 @@ func (store *SchemaStore) loadSchema(fullName string) (*Schema, error) {
 		return nil, err
 	}
 
+	if store.schemas == nil {
+		store.schemas = map[string]*Schema{}
+	}
 	store.schemas[fullName] = schema
 	return schema, nil
 }
@@ -3,6 +3,7 @@ package avroturf_test
 import (
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/rakyll/statik/fs"
@@ -50,3 +51,35 @@ func TestFind(t *testing.T) {
 		t.Errorf("expected %v by %v", s, schema)
 	}
 }
+
+func TestFindOnParallel(t *testing.T) {
+	store := &avroturf.SchemaStore{Path: "testdata"}
+	names := []string{"test-name", "test-reader"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				name := names[(i+j)%len(names)]
+				schema, err := store.Find(name, "test-namespace")
+				if err != nil {
+					t.Errorf("unexpected err: %v", err)
+					return
+				}
+				if schema == nil {
+					t.Errorf("expected schema for %s", name)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	first, _ := store.Find("test-name", "test-namespace")
+	second, _ := store.Find("test-name", "test-namespace")
+	if first != second {
+		t.Error("expected schema to be loaded once and shared")
+	}
+}
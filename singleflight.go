@@ -0,0 +1,60 @@
+package avroturf
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+type flightCall struct {
+	done     chan struct{}
+	val      interface{}
+	err      error
+	canceled bool
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	for {
+		g.mu.Lock()
+		if g.calls == nil {
+			g.calls = map[string]*flightCall{}
+		}
+		c, hit := g.calls[key]
+		if !hit {
+			c = &flightCall{done: make(chan struct{})}
+			g.calls[key] = c
+			g.mu.Unlock()
+			g.call(ctx, key, c, fn)
+			return c.val, c.err
+		}
+		g.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.done:
+		}
+		if !c.canceled {
+			return c.val, c.err
+		}
+	}
+}
+
+func (g *flightGroup) call(ctx context.Context, key string, c *flightCall, fn func() (interface{}, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.val, c.err = nil, fmt.Errorf("panic during fetch: %v", r)
+		}
+		c.canceled = c.err != nil && ctx.Err() != nil
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+	c.val, c.err = fn()
+}
@@ -0,0 +1,123 @@
+package avroturf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+type StaticSchemaRegistry struct {
+	schemasByID map[uint32]*Schema
+	subjects    map[string][]*RegisteredSchema
+}
+
+func NewStaticSchemaRegistry(store *SchemaStore, manifestPath string) (*StaticSchemaRegistry, error) {
+	manifest, schemas, err := loadManifest(store, manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	r := &StaticSchemaRegistry{
+		schemasByID: schemas,
+		subjects:    map[string][]*RegisteredSchema{},
+	}
+	for _, subject := range manifest.Subjects {
+		for _, version := range subject.Versions {
+			schema := schemas[version.ID]
+			r.subjects[subject.Subject] = append(r.subjects[subject.Subject], &RegisteredSchema{Subject: subject.Subject, Version: version.Version, ID: version.ID, Schema: schema})
+		}
+		versions := r.subjects[subject.Subject]
+		sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	}
+	return r, nil
+}
+
+func (r *StaticSchemaRegistry) FetchSchema(schemaID uint32) (*Schema, error) {
+	return r.FetchSchemaContext(context.Background(), schemaID)
+}
+
+func (r *StaticSchemaRegistry) FetchSchemaContext(ctx context.Context, schemaID uint32) (*Schema, error) {
+	schema, hit := r.schemasByID[schemaID]
+	if !hit {
+		return nil, &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeSchemaNotFound, Message: fmt.Sprintf("Schema %d not found", schemaID)}
+	}
+	return schema, nil
+}
+
+func (r *StaticSchemaRegistry) Register(subject string, schema *Schema) (uint32, error) {
+	return r.RegisterContext(context.Background(), subject, schema)
+}
+
+func (r *StaticSchemaRegistry) RegisterContext(ctx context.Context, subject string, schema *Schema) (uint32, error) {
+	registered, err := r.LookupSchemaContext(ctx, subject, schema)
+	if err != nil {
+		return 0, err
+	}
+	return registered.ID, nil
+}
+
+func (r *StaticSchemaRegistry) FetchSchemaBySubjectVersion(subject string, version int) (*RegisteredSchema, error) {
+	return r.FetchSchemaBySubjectVersionContext(context.Background(), subject, version)
+}
+
+func (r *StaticSchemaRegistry) FetchSchemaBySubjectVersionContext(ctx context.Context, subject string, version int) (*RegisteredSchema, error) {
+	versions, hit := r.subjects[subject]
+	if !hit {
+		return nil, subjectNotFound(subject)
+	}
+	if version == LatestVersion {
+		return versions[len(versions)-1], nil
+	}
+	for _, registered := range versions {
+		if registered.Version == version {
+			return registered, nil
+		}
+	}
+	return nil, &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeVersionNotFound, Message: fmt.Sprintf("Version %d not found", version)}
+}
+
+func (r *StaticSchemaRegistry) LookupSchema(subject string, schema *Schema) (*RegisteredSchema, error) {
+	return r.LookupSchemaContext(context.Background(), subject, schema)
+}
+
+func (r *StaticSchemaRegistry) LookupSchemaContext(ctx context.Context, subject string, schema *Schema) (*RegisteredSchema, error) {
+	versions, hit := r.subjects[subject]
+	if !hit {
+		return nil, subjectNotFound(subject)
+	}
+	for _, registered := range versions {
+		if registered.Schema.String() == schema.String() {
+			return registered, nil
+		}
+	}
+	return nil, &RegistryError{StatusCode: http.StatusNotFound, ErrorCode: ErrorCodeSchemaNotFound, Message: fmt.Sprintf("Schema not found in subject %s", subject)}
+}
+
+func (r *StaticSchemaRegistry) ListSubjects() ([]string, error) {
+	return r.ListSubjectsContext(context.Background())
+}
+
+func (r *StaticSchemaRegistry) ListSubjectsContext(ctx context.Context) ([]string, error) {
+	subjects := make([]string, 0, len(r.subjects))
+	for subject := range r.subjects {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	return subjects, nil
+}
+
+func (r *StaticSchemaRegistry) ListVersions(subject string) ([]int, error) {
+	return r.ListVersionsContext(context.Background(), subject)
+}
+
+func (r *StaticSchemaRegistry) ListVersionsContext(ctx context.Context, subject string) ([]int, error) {
+	registered, hit := r.subjects[subject]
+	if !hit {
+		return nil, subjectNotFound(subject)
+	}
+	versions := make([]int, len(registered))
+	for i, v := range registered {
+		versions[i] = v.Version
+	}
+	return versions, nil
+}
@@ -0,0 +1,165 @@
+package avroturf_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+	"github.com/wanabe/avroturf-go/avroturftest"
+)
+
+func TestStaticSchemaRegistry(t *testing.T) {
+	srv := avroturftest.NewRegistry()
+	defer srv.Close()
+	live := srv.SchemaRegistry()
+
+	v1 := mustParse(t, `{"type":"record","name":"TestSchemaRoot","fields":[{"name":"str","type":"string"}]}`)
+	v2 := mustParse(t, `{"type":"record","name":"TestSchemaRoot","fields":[{"name":"str","type":"string"},{"name":"num","type":"long","default":7}]}`)
+	unknown := mustParse(t, `"string"`)
+	for _, reg := range []struct {
+		subject string
+		schema  *avroturf.Schema
+	}{{"other-value", v2}, {"test-value", v1}, {"test-value", v2}} {
+		if _, err := live.Register(reg.subject, reg.schema); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "avroturf-static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	manifest, err := avroturf.ExportManifest(context.Background(), live, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Schemas) != 2 || len(manifest.Subjects) != 2 {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+
+	for _, store := range []*avroturf.SchemaStore{
+		avroturf.NewSchemaStore(dir),
+		{Path: "/", FS: http.Dir(dir)},
+	} {
+		r, err := avroturf.NewStaticSchemaRegistry(store, avroturf.ManifestFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		schema, err := r.FetchSchema(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if schema.String() != v1.String() {
+			t.Errorf("expected %v but got %v", v1, schema)
+		}
+		id, err := r.Register("test-value", v2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 1 {
+			t.Errorf("expected id 1 but got %d", id)
+		}
+		if _, err := r.Register("test-value", unknown); !avroturf.IsSchemaNotFound(err) {
+			t.Errorf("expected schema not found but got %v", err)
+		}
+		if _, err := r.Register("missing-value", v1); !avroturf.IsSubjectNotFound(err) {
+			t.Errorf("expected subject not found but got %v", err)
+		}
+		latest, err := r.FetchSchemaBySubjectVersion("test-value", avroturf.LatestVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if latest.Version != 2 || latest.ID != 1 {
+			t.Errorf("unexpected latest: %+v", latest)
+		}
+		versions, err := r.ListVersions("test-value")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := []int{1, 2}; !reflect.DeepEqual(expected, versions) {
+			t.Errorf("expected %v but got %v", expected, versions)
+		}
+
+		messaging := avroturf.NewMessagingWithRegistry("test-namespace", "testdata/", r)
+		data, err := messaging.Encode(record{Str: "hoge"}, "test-value", "test-name", "test-namespace")
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := record{}
+		if err := messaging.Decode(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Str != "hoge" {
+			t.Errorf("expected hoge but got %s", decoded.Str)
+		}
+	}
+}
+
+func TestStaticSchemaRegistryYAMLManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "avroturf-static")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"schemas/1.avsc": `{"type":"record","name":"TestSchemaRoot","fields":[{"name":"str","type":"string"}]}`,
+		"schemas/2.avsc": `"string"`,
+		"manifest.yaml": `# exported by hand
+compatibility: BACKWARD
+schemas:
+  - id: 1
+    file: schemas/1.avsc
+  - id: 2
+    file: 'schemas/2.avsc' # quoted
+subjects:
+- subject: "test: value"
+  compatibility: FULL
+  versions:
+    - version: 1
+      id: 2
+    - {version: 2, id: 1}
+`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := avroturf.NewStaticSchemaRegistry(avroturf.NewSchemaStore(dir), "manifest.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions, err := r.ListVersions("test: value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []int{1, 2}; !reflect.DeepEqual(expected, versions) {
+		t.Errorf("expected %v but got %v", expected, versions)
+	}
+	latest, err := r.FetchSchemaBySubjectVersion("test: value", avroturf.LatestVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.ID != 1 || latest.Schema.String() != mustParse(t, files["schemas/1.avsc"]).String() {
+		t.Errorf("unexpected latest: %+v", latest)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "manifest.yml"), []byte("schemas: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = avroturf.NewStaticSchemaRegistry(avroturf.NewSchemaStore(dir), "manifest.yml")
+	if err == nil || !strings.Contains(err.Error(), "manifest.yml: yaml:") {
+		t.Errorf("expected a YAML syntax error but got %v", err)
+	}
+}
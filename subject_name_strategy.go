@@ -0,0 +1,50 @@
+package avroturf
+
+import (
+	"fmt"
+
+	"github.com/hamba/avro"
+)
+
+type SubjectNameStrategy interface {
+	SubjectName(topic string, isKey bool, schema *Schema) (string, error)
+}
+
+type TopicNameStrategy struct{}
+
+type RecordNameStrategy struct{}
+
+type TopicRecordNameStrategy struct{}
+
+func (TopicNameStrategy) SubjectName(topic string, isKey bool, schema *Schema) (string, error) {
+	if topic == "" {
+		return "", fmt.Errorf("topic is required for TopicNameStrategy")
+	}
+	if isKey {
+		return topic + "-key", nil
+	}
+	return topic + "-value", nil
+}
+
+func (RecordNameStrategy) SubjectName(topic string, isKey bool, schema *Schema) (string, error) {
+	return recordFullName(schema)
+}
+
+func (TopicRecordNameStrategy) SubjectName(topic string, isKey bool, schema *Schema) (string, error) {
+	if topic == "" {
+		return "", fmt.Errorf("topic is required for TopicRecordNameStrategy")
+	}
+	name, err := recordFullName(schema)
+	if err != nil {
+		return "", err
+	}
+	return topic + "-" + name, nil
+}
+
+func recordFullName(schema *Schema) (string, error) {
+	s, ok := schema.Schema.(avro.NamedSchema)
+	if !ok {
+		return "", fmt.Errorf("schema has no name: %s", schema.String())
+	}
+	return s.FullName(), nil
+}
@@ -0,0 +1,68 @@
+package avroturf_test
+
+import (
+	"testing"
+
+	"github.com/wanabe/avroturf-go"
+)
+
+func TestSubjectNameStrategies(t *testing.T) {
+	schema, err := avroturf.Parse(`
+		{
+			"type": "record",
+			"name": "TestSchema",
+			"namespace": "com.example",
+			"fields": [
+				{
+					"type": "string",
+					"name": "str"
+				}
+			]
+		}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		strategy avroturf.SubjectNameStrategy
+		isKey    bool
+		expected string
+	}{
+		{avroturf.TopicNameStrategy{}, false, "topic-value"},
+		{avroturf.TopicNameStrategy{}, true, "topic-key"},
+		{avroturf.RecordNameStrategy{}, false, "com.example.TestSchema"},
+		{avroturf.RecordNameStrategy{}, true, "com.example.TestSchema"},
+		{avroturf.TopicRecordNameStrategy{}, false, "topic-com.example.TestSchema"},
+		{avroturf.TopicRecordNameStrategy{}, true, "topic-com.example.TestSchema"},
+	}
+	for _, test := range tests {
+		subject, err := test.strategy.SubjectName("topic", test.isKey, schema)
+		if err != nil {
+			t.Errorf("unexpected err: %v", err)
+		}
+		if subject != test.expected {
+			t.Errorf("expected %s but got %s", test.expected, subject)
+		}
+	}
+}
+
+func TestFailSubjectNameStrategies(t *testing.T) {
+	schema, err := avroturf.Parse(`"string"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = avroturf.TopicNameStrategy{}.SubjectName("", false, schema)
+	if err == nil {
+		t.Error("expected error but got nil")
+	}
+	_, err = avroturf.RecordNameStrategy{}.SubjectName("topic", false, schema)
+	if err == nil {
+		t.Error("expected error but got nil")
+	}
+	_, err = avroturf.TopicRecordNameStrategy{}.SubjectName("topic", false, schema)
+	if err == nil {
+		t.Error("expected error but got nil")
+	}
+}